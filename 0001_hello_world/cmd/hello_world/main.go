@@ -0,0 +1,8 @@
+// Command hello_world runs the 0001_hello_world lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0001_hello_world"
+
+func main() {
+	helloworld.Run()
+}
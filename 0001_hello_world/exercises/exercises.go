@@ -0,0 +1,13 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0001_hello_world lesson: greet the world, or someone in it.
+//
+// Replace the TODOs and run "little-engine check 0001" to check your work.
+package exercises
+
+// Greet returns "Hello Go world!" when name is empty and
+// "Hello <name>!" otherwise.
+func Greet(name string) string {
+	// TODO: implement
+	return ""
+}
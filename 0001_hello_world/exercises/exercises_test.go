@@ -0,0 +1,18 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestGreet(t *testing.T) {
+	checker.Hint(t, "compare name with the empty string \"\" and build the greeting with the + operator")
+	for name, want := range map[string]string{"": "Hello Go world!", "Gopher": "Hello Gopher!"} {
+		if got := Greet(name); got != want {
+			t.Errorf("Greet(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
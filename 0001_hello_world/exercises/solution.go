@@ -0,0 +1,10 @@
+//go:build solution
+
+package exercises
+
+func Greet(name string) string {
+	if name == "" {
+		return "Hello Go world!"
+	}
+	return "Hello " + name + "!"
+}
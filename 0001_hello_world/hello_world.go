@@ -1,6 +1,8 @@
 // a package clause starts every source file
 // main is a special name declaring an executable rather than a library (package)
-package main
+// this lesson is a library package so that little-engine can run it, the
+// executable lives in cmd/hello_world/main.go
+package helloworld
 
 // import declaration declares packages used in this file
 import "fmt"
@@ -11,9 +13,10 @@ var x int = 100
 const y = 0
 
 // a function declaration. main is a special function name
-// it is the entry point for the executable program
+// it is the entry point for the executable program. Run is called by
+// main in cmd/hello_world/main.go
 // Go uses brace brackets to delimit code blocks
-func main() {
+func Run() {
 
 	// Local Scoped Variables and Constants Declarations, calling functions etc
 	var a int = 7
@@ -0,0 +1,8 @@
+// Command variables_and_consts runs the 0002_variables_and_consts lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0002_variables_and_consts"
+
+func main() {
+	variables.Run()
+}
@@ -0,0 +1,15 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0002_variables_and_consts lesson: declare variables and constants.
+//
+// Replace the TODOs and run "little-engine check 0002" to check your work.
+package exercises
+
+// SecondsPerDay must be a constant holding the number of seconds in a day.
+const SecondsPerDay = 0 // TODO: compute from hours, minutes and seconds
+
+// Swap returns a and b in the opposite order.
+func Swap(a, b int) (int, int) {
+	// TODO: implement
+	return a, b
+}
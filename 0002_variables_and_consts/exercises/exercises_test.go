@@ -0,0 +1,23 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestSecondsPerDay(t *testing.T) {
+	checker.Hint(t, "constants can be built from other constant expressions, e.g. 24 * 60 * 60")
+	if SecondsPerDay != 86400 {
+		t.Errorf("SecondsPerDay = %d, want 86400", SecondsPerDay)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	checker.Hint(t, "go supports tuple assignment: a, b = b, a")
+	if a, b := Swap(1, 2); a != 2 || b != 1 {
+		t.Errorf("Swap(1, 2) = %d, %d, want 2, 1", a, b)
+	}
+}
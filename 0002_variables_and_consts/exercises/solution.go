@@ -0,0 +1,10 @@
+//go:build solution
+
+package exercises
+
+const SecondsPerDay = 24 * 60 * 60
+
+func Swap(a, b int) (int, int) {
+	a, b = b, a
+	return a, b
+}
@@ -1,10 +1,11 @@
-package main
+// Package variables is the lesson on declaring variables and constants.
+package variables
 
 import (
 	"fmt"
 )
 
-func main() {
+func Run() {
 	/*
 	   In traditional statically typed programming languages,
 	   variables are declared as <type> <identifier> = <value>
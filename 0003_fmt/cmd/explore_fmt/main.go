@@ -0,0 +1,8 @@
+// Command explore_fmt runs the 0003_fmt lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0003_fmt"
+
+func main() {
+	explorefmt.Run()
+}
@@ -0,0 +1,19 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0003_fmt lesson: format values with the fmt package.
+//
+// Replace the TODOs and run "little-engine check 0003" to check your work.
+package exercises
+
+// Price formats an item and its price with two decimals, e.g.
+// Price("coffee", 3.5) returns "coffee: $3.50".
+func Price(item string, price float64) string {
+	// TODO: implement with fmt.Sprintf
+	return ""
+}
+
+// TypeName returns the go type of v as printed by the %T verb.
+func TypeName(v interface{}) string {
+	// TODO: implement
+	return ""
+}
@@ -0,0 +1,29 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestPrice(t *testing.T) {
+	checker.Hint(t, "%.2f prints a float with two decimals and $ needs no escaping")
+	if got := Price("coffee", 3.5); got != "coffee: $3.50" {
+		t.Errorf(`Price("coffee", 3.5) = %q, want "coffee: $3.50"`, got)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	checker.Hint(t, "fmt.Sprintf returns what fmt.Printf would print")
+	cases := []struct {
+		v    interface{}
+		want string
+	}{{1, "int"}, {"a", "string"}, {[]float64{}, "[]float64"}}
+	for _, c := range cases {
+		if got := TypeName(c.v); got != c.want {
+			t.Errorf("TypeName(%#v) = %q, want %q", c.v, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build solution
+
+package exercises
+
+import "fmt"
+
+func Price(item string, price float64) string {
+	return fmt.Sprintf("%s: $%.2f", item, price)
+}
+
+func TypeName(v interface{}) string {
+	return fmt.Sprintf("%T", v)
+}
@@ -1,8 +1,9 @@
-package main
+// Package explorefmt is the lesson on printing and formatting with the fmt package.
+package explorefmt
 
 import "fmt"
 
-func main() {
+func Run() {
 	// fmt.Println can print a line
 	fmt.Println("This is a line")
 	// fmt.Println can append values to a line
@@ -0,0 +1,8 @@
+// Command data_types runs the 0004_data_types lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0004_data_types"
+
+func main() {
+	datatypes.Run()
+}
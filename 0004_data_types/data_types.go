@@ -1,11 +1,12 @@
-package main
+// Package datatypes is the lesson on go's basic data types and conversions between them.
+package datatypes
 
 import (
 	"fmt"
 	"strconv"
 )
 
-func main() {
+func Run() {
 	/*
 	  There are 4 types to represent integers:
 	  int8 => 8 bit integers -128 to 127.
@@ -100,7 +101,7 @@ func main() {
 	/*
 	  Converting numeric values to string.
 	*/
-	s1 := string(99) // unicode code point for 99 i.e. c
+	s1 := string(rune(99)) // unicode code point for 99 i.e. c
 	fmt.Println("99 is:", s1)
 	// s_1 := string(3.12)  // Compile time error
 	str1 := fmt.Sprintf("%f", 3.12)
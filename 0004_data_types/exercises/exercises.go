@@ -0,0 +1,13 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0004_data_types lesson: convert between data types.
+//
+// Replace the TODOs and run "little-engine check 0004" to check your work.
+package exercises
+
+// Double parses s as an integer and returns twice its value. It returns an
+// error if s isn't an integer.
+func Double(s string) (int, error) {
+	// TODO: implement with the strconv package
+	return 0, nil
+}
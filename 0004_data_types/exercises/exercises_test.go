@@ -0,0 +1,19 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestDouble(t *testing.T) {
+	checker.Hint(t, "strconv.Atoi converts a string to an int and returns an error for invalid input")
+	if got, err := Double("21"); err != nil || got != 42 {
+		t.Errorf(`Double("21") = %d, %v, want 42, nil`, got, err)
+	}
+	if _, err := Double("4two"); err == nil {
+		t.Error(`Double("4two") returned no error`)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build solution
+
+package exercises
+
+import "strconv"
+
+func Double(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return 2 * n, nil
+}
@@ -0,0 +1,8 @@
+// Command operators runs the 0005_operators lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0005_operators"
+
+func main() {
+	operators.Run()
+}
@@ -0,0 +1,18 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0005_operators lesson: use arithmetic and bitwise operators.
+//
+// Replace the TODOs and run "little-engine check 0005" to check your work.
+package exercises
+
+// IsEven reports whether n is even.
+func IsEven(n int) bool {
+	// TODO: implement
+	return false
+}
+
+// SetBit returns n with bit i set to 1.
+func SetBit(n uint, i uint) uint {
+	// TODO: implement with the bitwise operators
+	return n
+}
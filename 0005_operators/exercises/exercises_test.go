@@ -0,0 +1,28 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestIsEven(t *testing.T) {
+	checker.Hint(t, "the modulus operator % returns the remainder of a division")
+	for n, want := range map[int]bool{0: true, 1: false, 2: true, -3: false} {
+		if got := IsEven(n); got != want {
+			t.Errorf("IsEven(%d) = %t, want %t", n, got, want)
+		}
+	}
+}
+
+func TestSetBit(t *testing.T) {
+	checker.Hint(t, "1 << i has only bit i set, combine it with n using |")
+	if got := SetBit(0b1000, 1); got != 0b1010 {
+		t.Errorf("SetBit(0b1000, 1) = %b, want 1010", got)
+	}
+	if got := SetBit(0b1010, 1); got != 0b1010 {
+		t.Errorf("SetBit(0b1010, 1) = %b, want 1010", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build solution
+
+package exercises
+
+func IsEven(n int) bool {
+	return n%2 == 0
+}
+
+func SetBit(n uint, i uint) uint {
+	return n | 1<<i
+}
@@ -1,8 +1,9 @@
-package main
+// Package operators is the lesson on arithmetic, comparison, logical and bitwise operators.
+package operators
 
 import "fmt"
 
-func main() {
+func Run() {
 	/*
 	  Arithmetic operators apply to numeric values & are
 	  used to perform common mathematical operations.
@@ -0,0 +1,8 @@
+// Command flow_control runs the 0006_flow_control lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0006_flow_control"
+
+func main() {
+	flowcontrol.Run()
+}
@@ -0,0 +1,14 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0006_flow_control lesson: use loops and switch statements.
+//
+// Replace the TODOs and run "little-engine check 0006" to check your work.
+package exercises
+
+// FizzBuzz returns the numbers from 1 to n as strings, replacing multiples
+// of 3 with "Fizz", multiples of 5 with "Buzz" and multiples of both with
+// "FizzBuzz".
+func FizzBuzz(n int) []string {
+	// TODO: implement with a for loop and a switch statement
+	return nil
+}
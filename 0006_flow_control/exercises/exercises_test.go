@@ -0,0 +1,19 @@
+//go:build checker
+
+package exercises
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestFizzBuzz(t *testing.T) {
+	checker.Hint(t, "a switch without a condition picks the first true case, so check multiples of 15 first")
+	want := []string{"1", "2", "Fizz", "4", "Buzz", "Fizz", "7", "8", "Fizz", "Buzz", "11", "Fizz", "13", "14", "FizzBuzz"}
+	got := FizzBuzz(15)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("FizzBuzz(15) = %v, want %v", got, want)
+	}
+}
@@ -1,4 +1,5 @@
-package main
+// Package flowcontrol is the lesson on if-else, for loops, labels and switch statements.
+package flowcontrol
 
 import (
 	"fmt"
@@ -120,7 +121,7 @@ func switchFunc() {
 	}
 }
 
-func main() {
+func Run() {
 	/*
 	  Typical if-else if-else control flow.
 	*/
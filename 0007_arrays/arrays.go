@@ -1,8 +1,9 @@
-package main
+// Package arrays is the lesson on fixed size arrays.
+package arrays
 
 import "fmt"
 
-func main() {
+func Run() {
 	// Array is a composite indexable type of fixed lenght
 	// containing elements of same type
 	var numbers [4]int // array of 4 intergers
@@ -0,0 +1,8 @@
+// Command arrays runs the 0007_arrays lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0007_arrays"
+
+func main() {
+	arrays.Run()
+}
@@ -0,0 +1,12 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0007_arrays lesson: work with fixed size arrays.
+//
+// Replace the TODOs and run "little-engine check 0007" to check your work.
+package exercises
+
+// Reverse returns the elements of a in reverse order.
+func Reverse(a [5]int) [5]int {
+	// TODO: implement
+	return a
+}
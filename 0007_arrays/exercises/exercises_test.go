@@ -0,0 +1,20 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestReverse(t *testing.T) {
+	checker.Hint(t, "arrays are passed by value, so a is a copy you can modify and return")
+	in := [5]int{1, 2, 3, 4, 5}
+	if got, want := Reverse(in), [5]int{5, 4, 3, 2, 1}; got != want {
+		t.Errorf("Reverse(%v) = %v, want %v", in, got, want)
+	}
+	if in != [5]int{1, 2, 3, 4, 5} {
+		t.Errorf("Reverse changed its argument to %v", in)
+	}
+}
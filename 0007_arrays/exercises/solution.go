@@ -0,0 +1,10 @@
+//go:build solution
+
+package exercises
+
+func Reverse(a [5]int) [5]int {
+	for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+		a[i], a[j] = a[j], a[i]
+	}
+	return a
+}
@@ -0,0 +1,8 @@
+// Command slices runs the 0008_slices lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0008_slices"
+
+func main() {
+	slices.Run()
+}
@@ -0,0 +1,13 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0008_slices lesson: slice slices without surprises.
+//
+// Replace the TODOs and run "little-engine check 0008" to check your work.
+package exercises
+
+// Chunk splits s into slices of at most size elements. Appending to a chunk
+// must not overwrite the elements of the next chunk.
+func Chunk(s []int, size int) [][]int {
+	// TODO: implement
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build checker
+
+package exercises
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestChunk(t *testing.T) {
+	checker.Hint(t, "s[low:high] shares the backing array of s, remember the slice header explanation in the lesson")
+	got := Chunk([]int{1, 2, 3, 4, 5}, 2)
+	if want := "[[1 2] [3 4] [5]]"; fmt.Sprint(got) != want {
+		t.Fatalf("Chunk([1 2 3 4 5], 2) = %v, want %v", got, want)
+	}
+}
+
+func TestChunkAppend(t *testing.T) {
+	checker.Hint(t, "the full slice expression s[low:high:max] limits the capacity of a chunk")
+	got := Chunk([]int{1, 2, 3, 4}, 2)
+	if len(got) != 2 {
+		t.Fatalf("Chunk([1 2 3 4], 2) returned %d chunks, want 2", len(got))
+	}
+	_ = append(got[0], 99)
+	if got[1][0] != 3 {
+		t.Errorf("appending to the first chunk changed the second chunk to %v", got[1])
+	}
+}
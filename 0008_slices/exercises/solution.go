@@ -0,0 +1,15 @@
+//go:build solution
+
+package exercises
+
+func Chunk(s []int, size int) [][]int {
+	var chunks [][]int
+	for size < len(s) {
+		chunks = append(chunks, s[:size:size])
+		s = s[size:]
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
@@ -1,11 +1,12 @@
-package main
+// Package slices is the lesson on slices and how they share their backing array.
+package slices
 
 import (
 	"fmt"
 	"unsafe"
 )
 
-func main() {
+func Run() {
 	// Slices is a dynamic Array and can shrink or grow
 	var defSlice []string
 	fmt.Println("defSlice == nil is", defSlice == nil)
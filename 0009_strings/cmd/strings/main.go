@@ -0,0 +1,8 @@
+// Command strings runs the 0009_strings lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0009_strings"
+
+func main() {
+	strs.Run()
+}
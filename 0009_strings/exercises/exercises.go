@@ -0,0 +1,13 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0009_strings lesson: handle strings rune by rune.
+//
+// Replace the TODOs and run "little-engine check 0009" to check your work.
+package exercises
+
+// Reverse returns s with its characters in reverse order. It must work for
+// any utf-8 text, e.g. Reverse("héllo") returns "olléh".
+func Reverse(s string) string {
+	// TODO: implement
+	return s
+}
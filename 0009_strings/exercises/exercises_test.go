@@ -0,0 +1,18 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestReverse(t *testing.T) {
+	checker.Hint(t, "indexing a string returns bytes, convert it to []rune to work with characters")
+	for in, want := range map[string]string{"": "", "go": "og", "héllo": "olléh", "日本語": "語本日"} {
+		if got := Reverse(in); got != want {
+			t.Errorf("Reverse(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
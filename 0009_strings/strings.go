@@ -1,4 +1,5 @@
-package main
+// Package strs is the lesson on strings, runes and the strings package.
+package strs
 
 import (
 	"fmt"
@@ -6,7 +7,7 @@ import (
 	"unicode/utf8"
 )
 
-func main() {
+func Run() {
 	// Strings in go are enclosed within double quotes and utf-8 encoded
 	s1 := "Hi!"
 	fmt.Println(s1)
@@ -0,0 +1,8 @@
+// Command maps runs the 0010_maps lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0010_maps"
+
+func main() {
+	maps.Run()
+}
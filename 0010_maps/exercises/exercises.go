@@ -0,0 +1,12 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0010_maps lesson: count with maps.
+//
+// Replace the TODOs and run "little-engine check 0010" to check your work.
+package exercises
+
+// WordCount returns how often each whitespace separated word occurs in s.
+func WordCount(s string) map[string]int {
+	// TODO: implement with strings.Fields and a map
+	return nil
+}
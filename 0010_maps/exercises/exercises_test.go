@@ -0,0 +1,21 @@
+//go:build checker
+
+package exercises
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestWordCount(t *testing.T) {
+	checker.Hint(t, "a missing key returns the zero value, so counts[w]++ works on a map created with make")
+	got := WordCount("the cat and the hat")
+	if want := "map[and:1 cat:1 hat:1 the:2]"; fmt.Sprint(got) != want {
+		t.Errorf("WordCount = %v, want %v", got, want)
+	}
+	if got := WordCount(""); got == nil {
+		t.Error(`WordCount("") returned a nil map, want an empty map`)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build solution
+
+package exercises
+
+import "strings"
+
+func WordCount(s string) map[string]int {
+	counts := make(map[string]int)
+	for _, w := range strings.Fields(s) {
+		counts[w]++
+	}
+	return counts
+}
@@ -1,8 +1,9 @@
-package main
+// Package maps is the lesson on maps.
+package maps
 
 import "fmt"
 
-func main() {
+func Run() {
 	// Maps store key:value pairs
 	// Constant get, set & deletion time as they're backed by hash tables
 	// Keys & Values are statically typed. All keys must be of same type.
@@ -83,8 +84,8 @@ func main() {
 	// compare 2 maps, we can compare their string representations
 	map1 := map[string]int{"hi": 2, "bye": 3}
 	map2 := map[string]int{"bye": 3, "hi": 2}
-	s1 := fmt.Sprintf("%s", map1)
-	s2 := fmt.Sprintf("%s", map2)
+	s1 := fmt.Sprintf("%v", map1)
+	s2 := fmt.Sprintf("%v", map2)
 	fmt.Println(s1)
 	fmt.Println(s2)
 	if s1 == s2 {
@@ -0,0 +1,43 @@
+// Command files runs the 0011_files lesson.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ssukruth/little-engine-go/0011_files"
+	"github.com/ssukruth/little-engine-go/pkg/input"
+)
+
+func main() {
+	var (
+		opts files.Options
+		src  = input.Source{HistoryFile: input.DefaultHistoryFile()}
+		name string
+	)
+	flag.StringVar(&opts.BaseDir, "dir", "", "create the scratchpad in `dir` instead of the temporary directory")
+	flag.BoolVar(&opts.Keep, "keep", false, "keep the scratchpad for inspection")
+	flag.StringVar(&name, "name", "", "answer the name prompt with `name`")
+	flag.StringVar(&src.File, "input", "", "read the answers from `file`, one per line")
+	flag.BoolVar(&src.Interactive, "interactive", false, "edit answers with arrow keys and history")
+	flag.Parse()
+	if name != "" {
+		src.Answers = []string{name}
+	}
+
+	if err := run(opts, src); err != nil {
+		fmt.Fprintln(os.Stderr, "files:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts files.Options, src input.Source) error {
+	stdin, done, err := src.Open(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer done()
+	opts.Stdin = stdin
+	return files.RunWith(opts)
+}
@@ -0,0 +1,12 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0011_files lesson: read files line by line.
+//
+// Replace the TODOs and run "little-engine check 0011" to check your work.
+package exercises
+
+// CountLines returns the number of lines in the file at path.
+func CountLines(path string) (int, error) {
+	// TODO: implement with bufio.Scanner
+	return 0, nil
+}
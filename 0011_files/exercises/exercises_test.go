@@ -0,0 +1,29 @@
+//go:build checker
+
+package exercises
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestCountLines(t *testing.T) {
+	checker.Hint(t, "bufio.Scanner.Scan returns false at the end of the file, don't forget to close the file")
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(path, []byte("first line\nsecond line\nthird line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := CountLines(path); err != nil || n != 3 {
+		t.Errorf("CountLines = %d, %v, want 3, nil", n, err)
+	}
+}
+
+func TestCountLinesMissing(t *testing.T) {
+	checker.Hint(t, "return the error of os.Open instead of ignoring it")
+	if _, err := CountLines(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("CountLines of a missing file returned no error")
+	}
+}
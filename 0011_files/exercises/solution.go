@@ -0,0 +1,23 @@
+//go:build solution
+
+package exercises
+
+import (
+	"bufio"
+	"os"
+)
+
+func CountLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		n++
+	}
+	return n, sc.Err()
+}
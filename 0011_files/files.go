@@ -1,15 +1,83 @@
-package main
+// Package files is the lesson on creating, writing and reading files.
+package files
 
 import (
 	"bufio"
+	"embed"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-func main() {
+//go:embed scratchpad
+var fixtures embed.FS
+
+// Options configures the scratchpad directory the lesson works in and where
+// it reads the learner's answers from.
+type Options struct {
+	// BaseDir is the directory the scratchpad is created in, the default
+	// directory for temporary files if empty.
+	BaseDir string
+	// Keep keeps the scratchpad for inspection instead of removing it after
+	// the lesson.
+	Keep bool
+	// Stdin is read in place of os.Stdin when it is not nil.
+	Stdin io.Reader
+}
+
+// Run runs the lesson in a temporary scratchpad.
+func Run() {
+	if err := RunWith(Options{}); err != nil {
+		panic(err)
+	}
+}
+
+// newScratchpad creates a new directory under base and copies the files
+// committed in scratchpad/ into it.
+func newScratchpad(base string) (string, error) {
+	dir, err := os.MkdirTemp(base, "little-engine-files-")
+	if err != nil {
+		return "", err
+	}
+	err = fs.WalkDir(fixtures, "scratchpad", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fixtures.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, strings.TrimPrefix(p, "scratchpad/")), data, 0644)
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// RunWith runs the lesson in a new scratchpad directory configured by opts.
+// Every run gets its own directory, so runs don't interfere with each other
+// and never change the files committed in scratchpad/.
+func RunWith(opts Options) error {
+	scratchpad, err := newScratchpad(opts.BaseDir)
+	if err != nil {
+		return err
+	}
+	if opts.Keep {
+		defer fmt.Println("Kept scratchpad", scratchpad)
+	} else {
+		defer os.RemoveAll(scratchpad)
+	}
+	// path returns the path of a file in the scratchpad
+	path := func(name string) string {
+		return filepath.Join(scratchpad, name)
+	}
+
 	// Common way to work with files is to use the "os" package
 	// It provides unifom behavior across all OSes. The design
 	// of the package is unix-like. However, the errpr handling
@@ -18,17 +86,16 @@ func main() {
 	// Creating new File
 	var newFile *os.File
 	fmt.Printf("newFile is of type %T\n", newFile)
-	var err error
 
-	newFile, err = os.Create("scratchpad/a.txt")
+	newFile, err = os.Create(path("a.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Create file failed with err:", err)
-		os.Exit(1)
+		return err
 	}
 	newFile.Close()
 	// This fails because the path "scratchpad/new/" isn't present
-	_, err = os.Create("scratchpad/new/b.txt")
+	_, err = os.Create(path("new/b.txt"))
 	if err != nil {
 		// SHOULD enter here
 		fmt.Println("Create file failed with err:", err)
@@ -37,29 +104,29 @@ func main() {
 	}
 
 	// Opening existing file. Opens in read mode by default
-	newFile, err = os.Open("scratchpad/a.txt")
+	newFile, err = os.Open(path("a.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file:", err)
-		os.Exit(1)
+		return err
 	}
 	newFile.Close()
 
 	// Opening a file in append mode
-	newFile, err = os.OpenFile("scratchpad/a.txt", os.O_APPEND, 0777)
+	newFile, err = os.OpenFile(path("a.txt"), os.O_APPEND, 0777)
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file:", err)
-		os.Exit(1)
+		return err
 	}
 	newFile.Close()
 
 	// Get stat of opened file
-	fileInfo, err := os.Stat("scratchpad/a.txt")
+	fileInfo, err := os.Stat(path("a.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to get file info:", err)
-		os.Exit(1)
+		return err
 	}
 	fmt.Println("File name:", fileInfo.Name())
 	fmt.Println("Is dir?:", fileInfo.IsDir())
@@ -67,7 +134,7 @@ func main() {
 	fmt.Println("File size:", fileInfo.Size())
 
 	// Stat can also be used to check if file doesn't exist
-	fileInfo, err = os.Stat("scratchpad/new/a.txt")
+	fileInfo, err = os.Stat(path("new/a.txt"))
 	if err != nil {
 		// SHOULD enter here
 		fmt.Println("File doesn't exist:", err)
@@ -75,8 +142,8 @@ func main() {
 	}
 
 	// Renaming a file
-	oldPath := "scratchpad/a.txt"
-	newPath := "scratchpad/a_new.txt"
+	oldPath := path("a.txt")
+	newPath := path("a_new.txt")
 	err = os.Rename(oldPath, newPath)
 	if err != nil {
 		// SHOULD NOT enter here
@@ -92,7 +159,7 @@ func main() {
 
 	// Writing byte slices to a file
 	newFile, err = os.OpenFile(
-		"scratchpad/b.txt",
+		path("b.txt"),
 		// create file if it doesn't exist and open in write mode
 		// or truncate file and open in write mode
 		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
@@ -119,7 +186,7 @@ func main() {
 	// 3. writing byte slice to file
 	// 4. closing the file
 	newByteSlice := []byte("foo bar\n")
-	err = ioutil.WriteFile("scratchpad/c.txt", newByteSlice, 0655)
+	err = ioutil.WriteFile(path("c.txt"), newByteSlice, 0655)
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to write to file:", err)
@@ -130,7 +197,7 @@ func main() {
 	// means to write to memory and flush i.e. write to file either when the
 	// buffer is full or when explicit flush is called
 	newFile, err = os.OpenFile(
-		"scratchpad/d.txt",
+		path("d.txt"),
 		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
 		0655)
 	if err != nil {
@@ -152,12 +219,12 @@ func main() {
 	}
 	fmt.Println("Bytes available in buffer:", buffWriter.Available())
 	fmt.Println("Bytes unflushed from buffer:", buffWriter.Buffered())
-	fileInfo, err = os.Stat("scratchpad/d.txt")
+	fileInfo, err = os.Stat(path("d.txt"))
 	fmt.Println("File size before flush:", fileInfo.Size())
 	// note: any content in the buffer will not be flushed to file if the file
 	// is closed without calling Flush
 	buffWriter.Flush()
-	fileInfo, err = os.Stat("scratchpad/d.txt")
+	fileInfo, err = os.Stat(path("d.txt"))
 	fmt.Println("File size after flush:", fileInfo.Size())
 	// We can reset buffer if we've not flushed data into file
 	bytesWritten, err = buffWriter.Write([]byte("third line\n"))
@@ -170,7 +237,7 @@ func main() {
 	newFile.Close()
 
 	// Reading from file
-	newFile, err = os.Open("scratchpad/d.txt")
+	newFile, err = os.Open(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file for read:", err)
@@ -191,7 +258,7 @@ func main() {
 	fmt.Println()
 
 	// io.ReadFull achieves a similar behavior
-	newFile, err = os.Open("scratchpad/d.txt")
+	newFile, err = os.Open(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file for read:", err)
@@ -211,7 +278,7 @@ func main() {
 	fmt.Println()
 
 	// ioutil.ReadAll reads all contents of the file
-	newFile, err = os.Open("scratchpad/d.txt")
+	newFile, err = os.Open(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file for read:", err)
@@ -231,7 +298,7 @@ func main() {
 
 	// ioutil.ReadFile takes care of opening the file, reading all data
 	// and closing the file
-	data, err = ioutil.ReadFile("scratchpad/d.txt")
+	data, err = ioutil.ReadFile(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to read file:", err)
@@ -246,7 +313,7 @@ func main() {
 	// Sometimes you need to read the file line by line or read the
 	// contents until you hit a delimiter. bufio.Scanner us useful in
 	// such scenarios.
-	newFile, err = os.Open("scratchpad/d.txt")
+	newFile, err = os.Open(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file for read:", err)
@@ -266,7 +333,7 @@ func main() {
 	newFile.Close()
 
 	// To scane word by word
-	newFile, err = os.Open("scratchpad/d.txt")
+	newFile, err = os.Open(path("d.txt"))
 	if err != nil {
 		// SHOULD NOT enter here
 		fmt.Println("Failed to open file for read:", err)
@@ -287,8 +354,13 @@ func main() {
 	}
 	newFile.Close()
 
-	// Reading from STDIN
-	scanner := bufio.NewScanner(os.Stdin)
+	// Reading from STDIN. Any io.Reader works with a scanner, which lets
+	// the caller pass the answers in instead, see Options.Stdin.
+	stdin := opts.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	scanner := bufio.NewScanner(stdin)
 	fmt.Printf("Enter your name:")
 	scanner.Scan()
 	fmt.Println("Hello", scanner.Text())
@@ -299,4 +371,5 @@ func main() {
 		fmt.Println("Successfully scanned input from STDIN")
 	}
 
+	return nil
 }
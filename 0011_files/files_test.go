@@ -0,0 +1,57 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/pkg/input"
+)
+
+func TestRunWithParallel(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("scratchpad", "d.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parallel subtests run once the group's function returned, so
+	// t.Run("group") returns when they are done
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"a", "b", "c", "d"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				base := t.TempDir()
+				if err := RunWith(Options{BaseDir: base, Stdin: input.Lines(name)}); err != nil {
+					t.Fatal(err)
+				}
+				if entries, _ := os.ReadDir(base); len(entries) != 0 {
+					t.Errorf("scratchpad wasn't removed, %s holds %v", base, entries)
+				}
+			})
+		}
+	})
+	got, err := os.ReadFile(filepath.Join("scratchpad", "d.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("lesson changed the committed scratchpad/d.txt to %q", got)
+	}
+}
+
+func TestRunWithKeep(t *testing.T) {
+	base := t.TempDir()
+	if err := RunWith(Options{BaseDir: base, Keep: true, Stdin: input.Lines("gopher")}); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := filepath.Glob(filepath.Join(base, "little-engine-files-*", "d.txt"))
+	if err != nil || len(kept) != 1 {
+		t.Fatalf("kept scratchpad not found: %v, %v", kept, err)
+	}
+	data, err := os.ReadFile(kept[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first line\nsecond line\n" {
+		t.Errorf("kept d.txt = %q", data)
+	}
+}
@@ -0,0 +1,8 @@
+// Command structs runs the 0012_structs lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0012_structs"
+
+func main() {
+	structs.Run()
+}
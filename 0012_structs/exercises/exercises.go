@@ -0,0 +1,17 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0012_structs lesson: define and use structs.
+//
+// Replace the TODOs and run "little-engine check 0012" to check your work.
+package exercises
+
+// Point is a point on a plane.
+type Point struct {
+	X, Y float64
+}
+
+// Distance returns the euclidean distance between p and q.
+func Distance(p, q Point) float64 {
+	// TODO: implement with math.Hypot or math.Sqrt
+	return 0
+}
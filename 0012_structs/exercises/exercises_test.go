@@ -0,0 +1,16 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestDistance(t *testing.T) {
+	checker.Hint(t, "access the fields with the dot operator, e.g. q.X - p.X")
+	if got := Distance(Point{1, 1}, Point{4, 5}); got != 5 {
+		t.Errorf("Distance({1 1}, {4 5}) = %v, want 5", got)
+	}
+}
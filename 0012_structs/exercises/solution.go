@@ -0,0 +1,13 @@
+//go:build solution
+
+package exercises
+
+import "math"
+
+type Point struct {
+	X, Y float64
+}
+
+func Distance(p, q Point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
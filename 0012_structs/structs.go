@@ -1,8 +1,9 @@
-package main
+// Package structs is the lesson on structs.
+package structs
 
 import "fmt"
 
-func main() {
+func Run() {
 	// Struct is a sequence of named elements called fields.
 	// Each field has a name and a type.
 	// Blueprint of data the structure holds.
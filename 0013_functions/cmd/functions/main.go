@@ -0,0 +1,8 @@
+// Command functions runs the 0013_functions lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0013_functions"
+
+func main() {
+	functions.Run()
+}
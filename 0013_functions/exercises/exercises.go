@@ -0,0 +1,19 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0013_functions lesson: write variadic functions and closures.
+//
+// Replace the TODOs and run "little-engine check 0013" to check your work.
+package exercises
+
+// Sum returns the sum of nums.
+func Sum(nums ...int) int {
+	// TODO: implement
+	return 0
+}
+
+// Fibonacci returns a closure that returns the next fibonacci number,
+// starting with 0, 1, 1, 2, 3, every time it's called.
+func Fibonacci() func() int {
+	// TODO: implement, see Counter in the lesson
+	return func() int { return 0 }
+}
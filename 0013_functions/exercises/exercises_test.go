@@ -0,0 +1,29 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestSum(t *testing.T) {
+	checker.Hint(t, "a variadic parameter is a slice inside the function")
+	if got := Sum(); got != 0 {
+		t.Errorf("Sum() = %d, want 0", got)
+	}
+	if got := Sum([]int{1, 2, 3, 4}...); got != 10 {
+		t.Errorf("Sum(1, 2, 3, 4) = %d, want 10", got)
+	}
+}
+
+func TestFibonacci(t *testing.T) {
+	checker.Hint(t, "declare the state outside the returned function so that the closure holds on to it")
+	f := Fibonacci()
+	for _, want := range []int{0, 1, 1, 2, 3, 5, 8, 13} {
+		if got := f(); got != want {
+			t.Fatalf("next fibonacci number is %d, want %d", got, want)
+		}
+	}
+}
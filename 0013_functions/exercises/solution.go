@@ -0,0 +1,20 @@
+//go:build solution
+
+package exercises
+
+func Sum(nums ...int) int {
+	sum := 0
+	for _, n := range nums {
+		sum += n
+	}
+	return sum
+}
+
+func Fibonacci() func() int {
+	a, b := 0, 1
+	return func() int {
+		f := a
+		a, b = b, a+b
+		return f
+	}
+}
@@ -1,4 +1,5 @@
-package main
+// Package functions is the lesson on functions, variadic functions, defer and closures.
+package functions
 
 import (
 	"fmt"
@@ -48,7 +49,9 @@ func f10() {
 	fmt.Println("Inside f10")
 }
 
-func counter(i int) func() int {
+// Counter returns a closure that increments and returns i every time
+// it's called.
+func Counter(i int) func() int {
 	fmt.Println("Initial value of counter:", i)
 	f := func() int {
 		i++
@@ -58,7 +61,7 @@ func counter(i int) func() int {
 	return f
 }
 
-func main() {
+func Run() {
 	// It is idiomatic to use camel case for function names.
 	// Within a package, function names should be unique.
 	// Go functions can return multiple values.
@@ -119,7 +122,7 @@ func main() {
 	}("I'm an anonymous function")
 	// Anonymous functions are typically used when a function returns another
 	// function which is defined inline
-	c := counter(0)
+	c := Counter(0)
 	c() // i = 1
 	c() // i = 2
 	c() // i = 3
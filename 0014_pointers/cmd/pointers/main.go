@@ -0,0 +1,8 @@
+// Command pointers runs the 0014_pointers lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0014_pointers"
+
+func main() {
+	pointers.Run()
+}
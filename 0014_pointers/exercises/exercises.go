@@ -0,0 +1,11 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0014_pointers lesson: change values through pointers.
+//
+// Replace the TODOs and run "little-engine check 0014" to check your work.
+package exercises
+
+// Swap exchanges the values a and b point to.
+func Swap(a, b *int) {
+	// TODO: implement
+}
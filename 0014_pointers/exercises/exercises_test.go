@@ -0,0 +1,18 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestSwap(t *testing.T) {
+	checker.Hint(t, "dereference the pointers with * to read and write the values they point to")
+	a, b := 1, 2
+	Swap(&a, &b)
+	if a != 2 || b != 1 {
+		t.Errorf("after Swap(&a, &b) a, b = %d, %d, want 2, 1", a, b)
+	}
+}
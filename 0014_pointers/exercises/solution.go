@@ -0,0 +1,7 @@
+//go:build solution
+
+package exercises
+
+func Swap(a, b *int) {
+	*a, *b = *b, *a
+}
@@ -1,4 +1,5 @@
-package main
+// Package pointers is the lesson on pointers.
+package pointers
 
 import "fmt"
 
@@ -53,7 +54,7 @@ func changeMap(m map[int]int) {
 	m[1] = 1
 }
 
-func main() {
+func Run() {
 	// A variable is a convenient alphanumeric nickname for
 	// a memory localtion. A pointer is a variable that stores the
 	// memory address of another variable.
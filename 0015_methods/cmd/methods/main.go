@@ -0,0 +1,8 @@
+// Command methods runs the 0015_methods lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0015_methods"
+
+func main() {
+	methods.Run()
+}
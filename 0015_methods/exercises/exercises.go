@@ -0,0 +1,22 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0015_methods lesson: define methods with value and pointer receivers.
+//
+// Replace the TODOs and run "little-engine check 0015" to check your work.
+package exercises
+
+// Rect is a rectangle of width W and height H.
+type Rect struct {
+	W, H float64
+}
+
+// Area returns the area of r.
+func (r Rect) Area() float64 {
+	// TODO: implement
+	return 0
+}
+
+// Scale multiplies the width and height of r by f.
+func (r Rect) Scale(f float64) {
+	// TODO: implement, is a value receiver the right choice?
+}
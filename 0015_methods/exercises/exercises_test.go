@@ -0,0 +1,25 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestArea(t *testing.T) {
+	checker.Hint(t, "the receiver's fields are accessed like the fields of any other struct value")
+	if got := (Rect{W: 2, H: 3}).Area(); got != 6 {
+		t.Errorf("Rect{2, 3}.Area() = %v, want 6", got)
+	}
+}
+
+func TestScale(t *testing.T) {
+	checker.Hint(t, "a method with a value receiver works on a copy, use a pointer receiver to change r")
+	r := Rect{W: 2, H: 3}
+	r.Scale(2)
+	if r.W != 4 || r.H != 6 {
+		t.Errorf("after Scale(2) r = %+v, want {W:4 H:6}", r)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build solution
+
+package exercises
+
+type Rect struct {
+	W, H float64
+}
+
+func (r Rect) Area() float64 {
+	return r.W * r.H
+}
+
+func (r *Rect) Scale(f float64) {
+	r.W *= f
+	r.H *= f
+}
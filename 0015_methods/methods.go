@@ -1,31 +1,37 @@
-package main
+// Package methods is the lesson on methods on named types.
+package methods
 
 import (
 	"fmt"
 	"time"
 )
 
-type names []string
-type day struct {
-	name  string
-	index int
+// Names is a named type with a method on it.
+type Names []string
+
+// Day is a day of the week.
+type Day struct {
+	Name  string
+	Index int
 }
 
-func (n names) printNames() {
-	fmt.Println("Inside printNames method of names type")
+// PrintNames prints every name in n on its own line.
+func (n Names) PrintNames() {
+	fmt.Println("Inside PrintNames method of Names type")
 	for _, name := range n {
 		fmt.Println(name)
 	}
-	fmt.Println("Exiting printNames method of names type")
+	fmt.Println("Exiting PrintNames method of Names type")
 }
 
-func (d *day) changeDay() {
+// ChangeDay changes d to Sunday.
+func (d *Day) ChangeDay() {
 	fmt.Println("Changing day to Sunday")
-	d.name = "Sunday"
-	d.index = 0
+	d.Name = "Sunday"
+	d.Index = 0
 }
 
-func main() {
+func Run() {
 	// Go doesn't have classes and objects but you can define
 	// methods on predefined types
 
@@ -36,19 +42,19 @@ func main() {
 	fmt.Printf("seconds in a day %v\n", seconds)
 
 	// Creating method for named types
-	namesType := names{"Joey", "Chandler"}
-	namesType.printNames()
+	namesType := Names{"Joey", "Chandler"}
+	namesType.PrintNames()
 
 	// Methods for pointer types
-	d := new(day) // creates a pointer to struct
-	d.name = "Monday"
-	d.index = 1
+	d := new(Day) // creates a pointer to struct
+	d.Name = "Monday"
+	d.Index = 1
 	fmt.Println("Before:", *d)
-	d.changeDay()
+	d.ChangeDay()
 	fmt.Println("After:", *d)
-	dd := day{name: "Tuesday", index: 2}
+	dd := Day{Name: "Tuesday", Index: 2}
 	fmt.Println("Before:", dd)
-	dd.changeDay() // go does the converstion of day to *day
+	dd.ChangeDay() // go does the converstion of day to *day
 	fmt.Println("After:", dd)
 
 	// Method declarations are not permitted on types that are pointers
@@ -0,0 +1,8 @@
+// Command interfaces runs the 0016_interfaces lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0016_interfaces"
+
+func main() {
+	interfaces.Run()
+}
@@ -0,0 +1,15 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0016_interfaces lesson: implement interfaces.
+//
+// Replace the TODOs and run "little-engine check 0016" to check your work.
+package exercises
+
+// Triangle is a triangle with sides A, B and C.
+//
+// TODO: implement the Area and Perimeter methods so that Triangle satisfies
+// the interfaces.Shapes interface. Heron's formula gives the area from the
+// sides: sqrt(s(s-a)(s-b)(s-c)) where s is half the perimeter.
+type Triangle struct {
+	A, B, C float64
+}
@@ -0,0 +1,25 @@
+//go:build checker
+
+package exercises
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/0016_interfaces"
+	"github.com/ssukruth/little-engine-go/internal/checker"
+)
+
+func TestTriangleIsShape(t *testing.T) {
+	checker.Hint(t, "a type satisfies an interface by implementing all of its methods: Area() float64 and Perimeter() float64")
+	sh, ok := interface{}(Triangle{3, 4, 5}).(interfaces.Shapes)
+	if !ok {
+		t.Fatal("Triangle doesn't implement interfaces.Shapes")
+	}
+	if got := sh.Perimeter(); got != 12 {
+		t.Errorf("Triangle{3, 4, 5}.Perimeter() = %v, want 12", got)
+	}
+	if got := sh.Area(); math.Abs(got-6) > 1e-9 {
+		t.Errorf("Triangle{3, 4, 5}.Area() = %v, want 6", got)
+	}
+}
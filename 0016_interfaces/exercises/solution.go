@@ -0,0 +1,18 @@
+//go:build solution
+
+package exercises
+
+import "math"
+
+type Triangle struct {
+	A, B, C float64
+}
+
+func (t Triangle) Perimeter() float64 {
+	return t.A + t.B + t.C
+}
+
+func (t Triangle) Area() float64 {
+	s := t.Perimeter() / 2
+	return math.Sqrt(s * (s - t.A) * (s - t.B) * (s - t.C))
+}
@@ -1,85 +1,94 @@
-package main
+// Package interfaces is the lesson on interfaces, type assertions and type switches.
+package interfaces
 
 import (
 	"fmt"
 	"math"
 )
 
-type circle struct {
-	radius float64
+// Circle is a round shape described by its radius.
+type Circle struct {
+	Radius float64
 }
 
-type square struct {
-	side float64
+// Square is a shape with four equal sides.
+type Square struct {
+	Side float64
 }
 
-type rectangle struct {
-	length  float64
-	breadth float64
+// Rectangle is a four sided shape with a length and a breadth.
+type Rectangle struct {
+	Length  float64
+	Breadth float64
 }
 
-func (c circle) perimeter() float64 {
-	return 2 * math.Pi * c.radius
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
 }
 
-func (c circle) area() float64 {
-	return math.Pi * c.radius * c.radius
+func (c Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
 }
 
-func (c circle) diameter() float64 {
-	return 2 * c.radius
+func (c Circle) Diameter() float64 {
+	return 2 * c.Radius
 }
 
-func (s square) perimeter() float64 {
-	return 4 * s.side
+func (s Square) Perimeter() float64 {
+	return 4 * s.Side
 }
 
-func (s square) area() float64 {
-	return math.Pow(s.side, 2)
+func (s Square) Area() float64 {
+	return math.Pow(s.Side, 2)
 }
 
-func (r rectangle) perimeter() float64 {
-	return 2 * (r.length + r.breadth)
+func (r Rectangle) Perimeter() float64 {
+	return 2 * (r.Length + r.Breadth)
 }
 
-func (r rectangle) area() float64 {
-	return r.length * r.breadth
+func (r Rectangle) Area() float64 {
+	return r.Length * r.Breadth
 }
 
 // Interface
-type shapes interface {
-	area() float64
-	perimeter() float64
+// Shapes is implemented by Circle, Square and Rectangle.
+type Shapes interface {
+	Area() float64
+	Perimeter() float64
 }
 
-func printShape(sh shapes) {
+// PrintShape prints the type, area and perimeter of sh.
+func PrintShape(sh Shapes) {
 	fmt.Printf("Shape: %T\n", sh)
-	fmt.Println("Area:", sh.area())
-	fmt.Println("Perimeter:", sh.perimeter())
+	fmt.Println("Area:", sh.Area())
+	fmt.Println("Perimeter:", sh.Perimeter())
 }
 
 // Embedded interface example
-type drawing interface {
-	shapes // all types implementing drawing must implement all methods of shapes
-	hasStraightLines() bool
+// Drawing is implemented by Circle.
+type Drawing interface {
+	Shapes // all types implementing drawing must implement all methods of shapes
+	HasStraightLines() bool
 }
 
-func (c circle) hasStraightLines() bool {
+func (c Circle) HasStraightLines() bool {
 	return false
 }
 
-func describe(d drawing) {
+// Describe prints the type, area, perimeter and straightness of d.
+func Describe(d Drawing) {
 	fmt.Printf("Drawing is of type %T\n", d)
-	fmt.Println("Area:", d.area())
-	fmt.Println("Perimeter:", d.perimeter())
-	fmt.Println("Can be drawn using a scale:", d.hasStraightLines())
+	fmt.Println("Area:", d.Area())
+	fmt.Println("Perimeter:", d.Perimeter())
+	fmt.Println("Can be drawn using a scale:", d.HasStraightLines())
 }
 
 // Empty interface
-type empty interface {
+// Empty is implemented by every type.
+type Empty interface {
 }
 
-func main() {
+func Run() {
 	// Interface is a collection of method signatures that
 	// an object (usually a named type) can implement. They
 	// define the behavior of an object and can implement
@@ -90,19 +99,19 @@ func main() {
 	// them. All 3 of these shapes can be represented by
 	// the shape interface
 
-	c1 := circle{radius: 5}
-	s1 := square{side: 5}
-	r1 := rectangle{length: 5, breadth: 2}
+	c1 := Circle{Radius: 5}
+	s1 := Square{Side: 5}
+	r1 := Rectangle{Length: 5, Breadth: 2}
 
-	printShape(c1)
-	printShape(s1)
-	printShape(r1)
+	PrintShape(c1)
+	PrintShape(s1)
+	PrintShape(r1)
 
 	// Variables with type as interface have nil type
 	// Intefaces implement polymorphism since the variables of interface
 	// type can dynamically take many values of types that implement
 	// the interface during runtime.
-	var s shapes
+	var s Shapes
 	fmt.Printf("s is of type %T\n", s)
 
 	s = c1 // this is valid since c1's type "circle" implements shape interface
@@ -122,29 +131,29 @@ func main() {
 
 	// To access methods of concrete type which are not defined in interface,
 	// we need type assertion.
-	c, ok := s.(circle)
+	c, ok := s.(Circle)
 	if !ok {
 		fmt.Println("Failed to get circle from shape")
 	}
-	fmt.Println("Diameter is:", c.diameter())
+	fmt.Println("Diameter is:", c.Diameter())
 
 	s = s1
 	// type switch
 	switch s.(type) {
-	case circle:
+	case Circle:
 		fmt.Println("s is a circle")
-	case rectangle:
+	case Rectangle:
 		fmt.Println("s is a rectangle")
-	case square:
+	case Square:
 		fmt.Println("s is a square")
 	}
 
 	// go doesn't support inheritance by extending interfaces
 	// Instead, we can embedd interfaces to achieve the same.
 
-	var d drawing
+	var d Drawing
 	d = c1
-	describe(d)
+	Describe(d)
 
 	// Circular interface embedding is prohibited by go
 	// Ex: The following is invalid
@@ -165,7 +174,7 @@ func main() {
 
 	// Empty interfaces do not define any methods
 	// Any type implements the empty interface
-	var e empty
+	var e Empty
 	e = 5
 	fmt.Println(e)
 
@@ -0,0 +1,8 @@
+// Command concurrency runs the 0017_concurrency lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0017_concurrency"
+
+func main() {
+	concurrency.Run()
+}
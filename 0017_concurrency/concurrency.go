@@ -1,10 +1,20 @@
-package main
+// Package concurrency is the lesson on goroutines, WaitGroups, mutexes and channels.
+package concurrency
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+	"github.com/ssukruth/little-engine-go/pkg/factorial"
+	"github.com/ssukruth/little-engine-go/pkg/interleave"
+	"github.com/ssukruth/little-engine-go/pkg/pubsub"
+	"github.com/ssukruth/little-engine-go/pkg/ratelimit"
 )
 
 func f1() {
@@ -23,22 +33,51 @@ func f1wg(wg *sync.WaitGroup) {
 	wg.Done()
 }
 
-func runTask(t string, wg *sync.WaitGroup) {
+// taskDuration returns how long a dummy task takes, 0 to 2 seconds.
+var taskDuration = func(task string) time.Duration {
+	return time.Duration(rand.Intn(3)) * time.Second
+}
+
+// RunTask is a dummy task that takes 0 to 2 seconds on clk to complete. It
+// stops early when ctx is cancelled or its deadline passes.
+func RunTask(ctx context.Context, clk clock.Clock, t string, wg *sync.WaitGroup) {
 	defer wg.Done()
 	fmt.Println("starting task", t)
-	s := rand.Intn(3)
-	time.Sleep(time.Duration(s) * time.Second)
+	if err := clock.Sleep(ctx, clk, taskDuration(t)); err != nil {
+		fmt.Println("gave up on task", t+":", err)
+		return
+	}
 	fmt.Println("done with task", t)
 }
 
-func runTaskWithChan(t string, c chan string) {
-	s := rand.Intn(3)
-	time.Sleep(time.Duration(s) * time.Second)
+// RunTaskWithChan is RunTask reporting completion on c instead of a
+// WaitGroup. Nothing is sent once ctx is cancelled, so the goroutine doesn't
+// block forever on a receiver that has gone away.
+func RunTaskWithChan(ctx context.Context, clk clock.Clock, t string, c chan<- string) {
+	if err := clock.Sleep(ctx, clk, taskDuration(t)); err != nil {
+		return
+	}
 	str := fmt.Sprintf("done with task %s", t)
-	c <- str
+	select {
+	case c <- str:
+	case <-ctx.Done():
+	}
+}
+
+// clk is the clock everything in the lesson waits on. Tests replace it with
+// a fake clock to run the lesson in no time.
+var clk = clock.Real()
+
+// SetClock makes the lesson wait on c instead of the real clock and returns
+// a function that puts the previous clock back. The golden output test runs
+// the lesson on a fake clock with it.
+func SetClock(c clock.Clock) (restore func()) {
+	orig := clk
+	clk = c
+	return func() { clk = orig }
 }
 
-func main() {
+func Run() {
 	// Concurrency is the first class citizen in go.
 	// Go is the first major language released after multicore cpu was released.
 	// Concurrency => loading more go routines at a time.
@@ -60,12 +99,19 @@ func main() {
 
 	// Goroutines can be spawned by using the "go" keyword
 	fmt.Println("Starting main program execution")
-	go f1()
+	f1Done := make(chan struct{})
+	go func() {
+		f1()
+		close(f1Done)
+	}()
 	f2()
 	fmt.Println("Finished calling f1 & f2")
-	// f1 executes in parallel with main. Wait for f1 to complete
-	// for now use sleep
-	time.Sleep(2 * time.Second)
+	// f1 executes in parallel with main and may not even have started yet.
+	// Sleeping for a while, e.g. time.Sleep(2 * time.Second), only works if
+	// f1 happens to finish in time and always wastes the rest of the wait.
+	// Instead f1's goroutine closes a channel when it's done, and receiving
+	// from a closed channel doesn't block.
+	<-f1Done
 	fmt.Println()
 
 	// The above synchronization problem can be solved using waitgroups
@@ -78,6 +124,16 @@ func main() {
 	fmt.Println("Finished executing all go routines")
 	fmt.Println()
 
+	// A goroutine can't be stopped from the outside. Instead every function
+	// that blocks takes a context.Context as its first argument and returns
+	// when the context is cancelled, which tells it its caller has lost
+	// interest. Cancelling a context also cancels every context derived
+	// from it, so cancelling the one created here stops everything started
+	// by this lesson. Always call cancel, at the latest when the function
+	// that created the context returns.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Let's say you need to perform n taks. Each task is represented by
 	// the dummy function runTask. Assuming each task takes x seconds to complete,
 	// executing them sequentially taks n*x seconds. On the other hand executing
@@ -86,9 +142,32 @@ func main() {
 	tasks := []string{"task1", "task2", "task3"}
 	exWg.Add(len(tasks))
 	for _, task := range tasks {
-		go runTask(task, &exWg)
+		go RunTask(ctx, clk, task, &exWg)
 	}
 	exWg.Wait()
+	// One goroutine per task doesn't scale to hundreds of thousands of
+	// tasks. pkg/workerpool runs them on a fixed number of goroutines
+	// instead.
+	fmt.Println()
+
+	// Starting every task at once can overload whatever the tasks talk to.
+	// A rate limiter spaces them out. The token bucket of ratelimit.New(2,
+	// 1, clk) gains two tokens per second and holds at most one, so Wait
+	// lets the first task start right away and every following one half a
+	// second after the one before.
+	limiter := ratelimit.New(2, 1, clk)
+	var rlWg sync.WaitGroup
+	throttled := clk.Now()
+	for _, task := range tasks {
+		if err := limiter.Wait(ctx); err != nil {
+			fmt.Println("not starting", task+":", err)
+			continue
+		}
+		fmt.Println("may start", task, "after", clk.Since(throttled).Truncate(500*time.Millisecond))
+		rlWg.Add(1)
+		go RunTask(ctx, clk, task, &rlWg)
+	}
+	rlWg.Wait()
 	fmt.Println()
 
 	// Unsynchronized access to memory leads to data races
@@ -146,9 +225,39 @@ func main() {
 
 	*/
 
+	// Whether the race above changes n depends on the machine, the load and
+	// luck, and -race only reports the races that happen in a run. To see
+	// the bug every time, pkg/interleave runs a model of the program in
+	// which every read and write of n is a separate step, under a
+	// scheduler that decides which goroutine takes the next step. Explore
+	// tries one schedule after the other until the final n is wrong.
+	racyCounter := func(r *interleave.Run) func() error {
+		n := interleave.NewVar(r, "n", 42)
+		r.Go("inc", func(t *interleave.Thread) { n.Store(t, n.Load(t)+1) })
+		r.Go("dec", func(t *interleave.Thread) { n.Store(t, n.Load(t)-1) })
+		return func() error {
+			if v := n.Value(); v != 42 {
+				return fmt.Errorf("n = %d, want 42", v)
+			}
+			return nil
+		}
+	}
+	res := interleave.Explore(racyCounter, interleave.Options{})
+	fmt.Println("schedules tried:", res.Schedules)
+	fmt.Println(res.Failure)
+	// Both goroutines read 42 before either writes, so one of the writes is
+	// lost. A schedule lists the goroutine that takes each step, which
+	// Replay runs again, step for step, as often as we like.
+	sched, _ := interleave.ParseSchedule("0,1,1,0")
+	_, err := interleave.Replay(racyCounter, sched)
+	fmt.Println("replaying 0,1,1,0:", err)
+	fmt.Println()
+
 	// Solving data races by Mutexes.
 	// code blocks enclosed within lock and unlock are called critical sections
 	// critical sections can be accessed by only one goroutine at any given time
+	// goroutines that only read can share a critical section with
+	// sync.RWMutex, see the 0019 lesson
 
 	orig, n = 0, 0
 	var mutexLock sync.Mutex
@@ -174,12 +283,105 @@ func main() {
 	fmt.Println("Is n value what we expected?", n == orig)
 	fmt.Println()
 
+	// A model of the program with the mutex and two goroutines of each
+	// kind passes every schedule. A goroutine waiting for the mutex can't
+	// take a step, so no other goroutine's steps end up between the read
+	// and the write.
+	lockedCounter := func(r *interleave.Run) func() error {
+		n := interleave.NewVar(r, "n", 42)
+		mu := interleave.NewMutex(r, "mutexLock")
+		for _, delta := range []int{1, -1, 1, -1} {
+			r.Go(fmt.Sprint("add ", delta), func(t *interleave.Thread) {
+				mu.Lock(t)
+				n.Store(t, n.Load(t)+delta)
+				mu.Unlock(t)
+			})
+		}
+		return func() error {
+			if v := n.Value(); v != 42 {
+				return fmt.Errorf("n = %d, want 42", v)
+			}
+			return nil
+		}
+	}
+	res = interleave.Explore(lockedCounter, interleave.Options{MaxPreemptions: -1})
+	fmt.Printf("schedules tried: %d, all of them: %v, failures: %v\n", res.Schedules, res.Complete, res.Failure != nil)
+	fmt.Println()
+
+	// Counters like n don't need a critical section at all. The functions
+	// of sync/atomic read and change a number in one step that no other
+	// goroutine can interrupt, so there is nothing to lock.
+	// atomic.Int64 wraps an int64 that is only accessed that way.
+
+	var an atomic.Int64
+	var adrWg sync.WaitGroup
+	adrWg.Add(200)
+
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer adrWg.Done()
+			an.Add(1)
+		}()
+		go func() {
+			defer adrWg.Done()
+			an.Add(-1)
+		}()
+	}
+	adrWg.Wait()
+	fmt.Println("n value is: ", an.Load())
+	fmt.Println("Is n value what we expected?", an.Load() == 0)
+	fmt.Println()
+
+	// Add only adds. Any other change is made with a CompareAndSwap loop:
+	// read the value, compute the new one and store it only if the value
+	// is still the one that was read. If another goroutine changed it in
+	// between, CompareAndSwap returns false and the loop tries again.
+	// This is how lock-free data structures are built, see pkg/lockfree.
+
+	var cn atomic.Int64
+	update := func(delta int64) {
+		for {
+			old := cn.Load()
+			if cn.CompareAndSwap(old, old+delta) {
+				return
+			}
+		}
+	}
+	var cdrWg sync.WaitGroup
+	cdrWg.Add(200)
+
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer cdrWg.Done()
+			update(1)
+		}()
+		go func() {
+			defer cdrWg.Done()
+			update(-1)
+		}()
+	}
+	cdrWg.Wait()
+	fmt.Println("n value is: ", cn.Load())
+	fmt.Println("Is n value what we expected?", cn.Load() == 0)
+	fmt.Println()
+
+	// Which one to use? Benchmark them on your machine with
+	// go test -bench Counter ./0017_concurrency
+	// An atomic Add is the cheapest and stays cheap as more goroutines
+	// contend. A CompareAndSwap loop costs about the same with little
+	// contention but retries more often with more of it. A mutex costs
+	// more per operation, and a channel the most, but both protect any
+	// number of variables at once. Use atomics for single counters and
+	// flags, a mutex for everything else.
+
 	// Data races can also be solved using channels.
 	// A channel in go provides a connection between two goroutines to communicate.
 	// Channels in go can communicate data only of the type defined during declaration.
 
 	// Channels are used in conjuntion with goroutines. Therefore the following code,
 	// even though valid, leads to deadlock.
+	// "little-engine diagnose 0017 deadlock" runs it and explains what the
+	// runtime reports.
 	/*
 		// Declaring a channel
 		var ch chan int // nil channel
@@ -226,11 +428,26 @@ func main() {
 	}
 	fmt.Println()
 
+	// The loop above waits for each goroutine right after starting it, so
+	// only one of them is ever running: that's concurrency without any
+	// parallelism. It also stops working at 21!, which doesn't fit in an
+	// int anymore. pkg/factorial multiplies with math/big and splits the
+	// numbers across goroutines that all run at the same time, one per cpu
+	// core, before multiplying their partial products. Its benchmarks show
+	// the speedup over computing the product on one goroutine.
+	big1000, err := factorial.ParallelFactorial(ctx, 1000, 0)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("1000! has", len(big1000.String()), "digits")
+	fmt.Println("Same as computed on one goroutine?", big1000.Cmp(factorial.Factorial(1000)) == 0)
+	fmt.Println()
+
 	// Refactoring the task code uwing channels
 
 	strChan := make(chan string)
 	for _, task := range tasks {
-		go runTaskWithChan(task, strChan)
+		go RunTaskWithChan(ctx, clk, task, strChan)
 	}
 
 	for i := 0; i < len(tasks); i++ {
@@ -250,22 +467,32 @@ func main() {
 	// operation is synchronized with receive operation.
 	c1 := make(chan int) //unbuffered channel
 
-	// Launching a goroutine
+	// Launching a goroutine. If nobody ever receives, the send blocks
+	// forever and the goroutine leaks, so it also gives up when the context
+	// is cancelled.
+	var c1Wg sync.WaitGroup
+	c1Wg.Add(1)
 	go func(c chan int) {
+		defer c1Wg.Done()
 		fmt.Println("anon func: before sending data")
-		c <- 10
-		fmt.Println("anon func: after sending data")
+		select {
+		case c <- 10:
+			fmt.Println("anon func: after sending data")
+		case <-ctx.Done():
+			fmt.Println("anon func: gave up sending data:", ctx.Err())
+		}
 	}(c1)
 
 	fmt.Println("main goroutine, sleeping for 2s")
-	time.Sleep(time.Second * 2)
+	clock.Sleep(ctx, clk, 2*time.Second)
 
 	fmt.Println("main goroutine receive data")
 	d := <-c1
 	fmt.Println("main goroutine received data:", d)
 
-	// we sleep for a second to give time to the goroutine to finish
-	time.Sleep(2 * time.Second)
+	// wait for the goroutine to finish rather than sleeping and hoping
+	// it's done by then
+	c1Wg.Wait()
 	fmt.Println()
 
 	// For buffered channels, the sender blocks only when there's no
@@ -283,41 +510,83 @@ func main() {
 	}(c2)
 
 	fmt.Println("main goroutine, sleeping for 2s")
-	time.Sleep(time.Second * 2)
+	clock.Sleep(ctx, clk, 2*time.Second)
 
 	for v := range c2 {
 		fmt.Println("main goroutine received data:", v)
 	}
+
+	// A goroutine sending on a channel that another goroutine ranges over
+	// is a pipeline with two stages. pkg/pipeline builds longer ones the
+	// same way, with cancellation and errors handled for every stage.
 	fmt.Println()
 
 	// Receiving value from closed channel leads to zero value of channel type
 	// Sending value to closed channel causes panic
+	// "little-engine diagnose 0017" lists this and other mistakes with
+	// channels and WaitGroups, ready to run and explained.
 
 	// Using select statements
 	// Select statement lets a goroutine wait on multiple communication operations
 
+	// The senders below are started with a context of their own that is
+	// cancelled once main stops listening. If the timeout wins a select
+	// more than once, one of them never gets its message across and
+	// would block forever without it.
+	selCtx, selCancel := context.WithCancel(ctx)
+	var selWg sync.WaitGroup
+	send := func(c chan<- string, msg string) {
+		defer selWg.Done()
+		if clock.Sleep(selCtx, clk, 2*time.Second) != nil {
+			return
+		}
+		select {
+		case c <- msg:
+		case <-selCtx.Done():
+		}
+	}
 	chan1, chan2 := make(chan string), make(chan string)
-
-	go func() {
-		time.Sleep(2 * time.Second)
-		chan1 <- "Hello!"
-	}()
-
-	go func() {
-		time.Sleep(2 * time.Second)
-		chan2 <- "Hey!"
-	}()
+	selWg.Add(2)
+	go send(chan1, "Hello!")
+	go send(chan2, "Hey!")
 
 	for i := 0; i < 3; i++ {
 		select {
-		case <-time.After(2 * time.Second):
+		case <-clk.After(2 * time.Second):
 			fmt.Println("Done with 2 seconds")
 		case msg := <-chan1:
 			fmt.Println("chan1:", msg)
 		case msg2 := <-chan2:
 			fmt.Println("chan2:", msg2)
+		case <-ctx.Done():
+			fmt.Println("cancelled:", ctx.Err())
 		}
 	}
+	selCancel()
+	selWg.Wait()
+	fmt.Println()
+
+	// select lets one goroutine listen to several senders. The reverse,
+	// one sender reaching any number of listeners, is publish/subscribe.
+	// pkg/pubsub gives every subscriber of a topic its own buffered
+	// channel and decides per subscriber what happens when it falls
+	// behind: the publisher waits (Block) or a message is dropped.
+	broker := pubsub.New[string]()
+	fast := broker.Subscribe("orders", pubsub.Name("fast"))
+	slowSub := broker.Subscribe("orders", pubsub.Name("slow"), pubsub.Buffer(1), pubsub.WithPolicy(pubsub.DropNewest))
+	for _, order := range []string{"order1", "order2", "order3"} {
+		broker.Publish(ctx, "orders", order)
+	}
+	broker.Close()
+	for order := range fast.C() {
+		fmt.Println("fast subscriber:", order)
+	}
+	for order := range slowSub.C() {
+		fmt.Println("slow subscriber:", order)
+	}
+	for _, st := range []pubsub.Stats{fast.Stats(), slowSub.Stats()} {
+		fmt.Printf("%s: delivered %d, dropped %d\n", st.Name, st.Delivered, st.Dropped)
+	}
 	fmt.Println()
 
 	// Refactoring the data race example above using channels
@@ -347,4 +616,46 @@ func main() {
 	fmt.Println("Is n value what we expected?", n == orig)
 	fmt.Println()
 
+	// Deadlines. context.WithTimeout returns a context that is cancelled
+	// on its own after the given duration, which bounds how long a single
+	// task may take. Its Err is context.DeadlineExceeded instead of
+	// context.Canceled.
+	slow := func(ctx context.Context, d time.Duration) error {
+		ctx, cancel := clock.WithTimeout(ctx, clk, time.Second)
+		defer cancel()
+		return clock.Sleep(ctx, clk, d)
+	}
+	fmt.Println("task taking 500ms with a 1s deadline:", slow(ctx, 500*time.Millisecond))
+	err = slow(ctx, 3*time.Second)
+	fmt.Println("task taking 3s with a 1s deadline:", err)
+	fmt.Println("deadline exceeded?", errors.Is(err, context.DeadlineExceeded))
+	fmt.Println()
+
+	// Cancelling a parent context stops every goroutine that watches a
+	// context derived from it. The workers below would otherwise run
+	// forever. The WaitGroup proves that all of them have returned.
+	parent, stop := context.WithCancel(ctx)
+	results := make(chan int)
+	var workers sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		workers.Add(1)
+		go func(ctx context.Context, id int) {
+			defer workers.Done()
+			for v := id; ; v += 3 {
+				select {
+				case results <- v:
+				case <-ctx.Done():
+					fmt.Println("worker", id, "exits:", ctx.Err())
+					return
+				}
+			}
+		}(parent, i)
+	}
+	for i := 0; i < 6; i++ {
+		<-results
+	}
+	stop()
+	workers.Wait()
+	fmt.Println("all workers exited after receiving 6 results")
+	fmt.Println()
 }
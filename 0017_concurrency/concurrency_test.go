@@ -0,0 +1,282 @@
+package concurrency
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestCancelledTasksReturn(t *testing.T) {
+	leakcheck.Check(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	c := make(chan string) // never received from
+	for _, task := range []string{"a", "b", "c", "d"} {
+		wg.Add(2)
+		go RunTask(ctx, clock.Real(), task, &wg)
+		go func() {
+			defer wg.Done()
+			RunTaskWithChan(ctx, clock.Real(), task, c)
+		}()
+	}
+	start := time.Now()
+	cancel()
+	wg.Wait()
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("tasks took %v to return after cancel", d)
+	}
+}
+
+// fixedDurations makes the dummy tasks take 2s, 0s and 1s.
+func fixedDurations(t *testing.T) {
+	durations := map[string]time.Duration{"task1": 2 * time.Second, "task2": 0, "task3": time.Second}
+	orig := taskDuration
+	taskDuration = func(task string) time.Duration { return durations[task] }
+	t.Cleanup(func() { taskDuration = orig })
+}
+
+// captureLines redirects os.Stdout until the end of the test and returns
+// the lines printed to it.
+func captureLines(t *testing.T) <-chan string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	lines := make(chan string, 1000)
+	go func() {
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		w.Close()
+		for range lines {
+		}
+		r.Close()
+	})
+	return lines
+}
+
+// next returns the next line, failing the test if nothing is printed.
+func next(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case l := <-lines:
+		return l
+	case <-time.After(time.Second):
+		t.Fatal("nothing printed")
+		return ""
+	}
+}
+
+func TestRunTaskInterleaving(t *testing.T) {
+	leakcheck.Check(t)
+	fixedDurations(t)
+	lines := captureLines(t)
+	fake := clock.NewFake(time.Time{})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for _, task := range []string{"task1", "task2", "task3"} {
+		go RunTask(ctx, fake, task, &wg)
+	}
+	// task2 takes no time, the others wait for the clock
+	fake.BlockUntil(2)
+	var started []string
+	for i := 0; i < 4; i++ {
+		if l := next(t, lines); l != "done with task task2" {
+			started = append(started, l)
+		}
+	}
+	if len(started) != 3 {
+		t.Fatalf("printed %q before the clock moved", started)
+	}
+	fake.Advance(999 * time.Millisecond)
+	fake.BlockUntil(2)
+	fake.Advance(time.Millisecond)
+	if l := next(t, lines); l != "done with task task3" {
+		t.Errorf("after 1s: %q", l)
+	}
+	fake.Advance(time.Second)
+	if l := next(t, lines); l != "done with task task1" {
+		t.Errorf("after 2s: %q", l)
+	}
+	wg.Wait()
+
+	c := make(chan string)
+	for _, task := range []string{"task1", "task2", "task3"} {
+		go RunTaskWithChan(ctx, fake, task, c)
+	}
+	if got := <-c; got != "done with task task2" {
+		t.Errorf("first result %q", got)
+	}
+	fake.BlockUntil(2)
+	fake.Advance(time.Second)
+	if got := <-c; got != "done with task task3" {
+		t.Errorf("result after 1s %q", got)
+	}
+	fake.Advance(time.Second)
+	if got := <-c; got != "done with task task1" {
+		t.Errorf("result after 2s %q", got)
+	}
+}
+
+func TestRunOnFakeClock(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the lesson demonstrates a data race")
+	}
+	leakcheck.Check(t)
+	fixedDurations(t)
+	fake := clock.NewFake(time.Time{})
+	orig := clk
+	clk = fake
+	defer func() { clk = orig }()
+	stop := make(chan struct{})
+	defer close(stop)
+	go fake.AutoAdvance(stop)
+
+	lines := captureLines(t)
+	start, virtualStart := time.Now(), fake.Now()
+	Run()
+	took, virtual := time.Since(start), fake.Since(virtualStart)
+	os.Stdout.Close()
+	var out []string
+	for l := range lines {
+		out = append(out, l)
+	}
+	if took > 3*time.Second {
+		t.Errorf("the lesson took %v on the fake clock", took)
+	}
+	// tasks, tasks with channels, two sleeps, select and the deadline
+	if virtual < 11*time.Second {
+		t.Errorf("the lesson took %v of virtual time, want at least 11s", virtual)
+	}
+
+	text := strings.Join(out, "\n")
+	paragraphs := strings.Split(text, "\n\n")
+	// virtual time orders the tasks by their duration
+	var done []string
+	for _, p := range paragraphs {
+		if !strings.Contains(p, "starting task") || strings.Contains(p, "may start") {
+			continue
+		}
+		for _, l := range strings.Split(p, "\n") {
+			if task, ok := strings.CutPrefix(l, "done with task "); ok {
+				done = append(done, task)
+			}
+		}
+	}
+	if got, want := strings.Join(done, " "), "task2 task3 task1"; got != want {
+		t.Errorf("tasks finished in the order %s, want %s:\n%s", got, want, text)
+	}
+	if !strings.Contains(text, "done with task task2\ndone with task task3\ndone with task task1") {
+		t.Errorf("the results of RunTaskWithChan aren't ordered by duration:\n%s", text)
+	}
+	// the rate limiter starts a task every 500ms
+	throttled := "may start task1 after 0s\n" +
+		"starting task task1\n" +
+		"may start task2 after 500ms\n" +
+		"starting task task2\n" +
+		"done with task task2\n" +
+		"may start task3 after 1s\n" +
+		"starting task task3\n"
+	if !strings.Contains(text, throttled) {
+		t.Errorf("the rate limited tasks didn't start in 500ms steps:\n%s", text)
+	}
+	// the buffered channel fills up while main sleeps
+	filled := strings.Index(text, "anon func: before sending data 3")
+	received := strings.Index(text, "main goroutine received data: 0")
+	if filled < 0 || received < filled {
+		t.Errorf("main received before the buffer was full:\n%s", text)
+	}
+	if !strings.Contains(text, "task taking 3s with a 1s deadline: context deadline exceeded") {
+		t.Errorf("the deadline didn't cut the slow task short:\n%s", text)
+	}
+}
+
+// counter is implemented by the fixes of the n++/n-- data race in Run.
+type counter interface{ add(delta int64) }
+
+type (
+	mutexCounter struct {
+		mu sync.Mutex
+		n  int64
+	}
+	chanCounter struct {
+		slot chan struct{}
+		n    int64
+	}
+	atomicCounter struct{ n atomic.Int64 }
+	casCounter    struct{ n atomic.Int64 }
+)
+
+func (c *mutexCounter) add(delta int64) {
+	c.mu.Lock()
+	c.n += delta
+	c.mu.Unlock()
+}
+
+func (c *chanCounter) add(delta int64) {
+	c.slot <- struct{}{}
+	c.n += delta
+	<-c.slot
+}
+
+func (c *atomicCounter) add(delta int64) { c.n.Add(delta) }
+
+func (c *casCounter) add(delta int64) {
+	for {
+		old := c.n.Load()
+		if c.n.CompareAndSwap(old, old+delta) {
+			return
+		}
+	}
+}
+
+// BenchmarkCounter compares the fixes of the data race with 1 to 64
+// goroutines adding to the same counter at the same time.
+func BenchmarkCounter(b *testing.B) {
+	counters := []struct {
+		name string
+		new  func() counter
+	}{
+		{"mutex", func() counter { return new(mutexCounter) }},
+		{"channel", func() counter { return &chanCounter{slot: make(chan struct{}, 1)} }},
+		{"atomic", func() counter { return new(atomicCounter) }},
+		{"cas", func() counter { return new(casCounter) }},
+	}
+	for _, c := range counters {
+		for _, goroutines := range []int{1, 4, 16, 64} {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", c.name, goroutines), func(b *testing.B) {
+				counter := c.new()
+				var wg sync.WaitGroup
+				wg.Add(goroutines)
+				b.ResetTimer()
+				for g := 0; g < goroutines; g++ {
+					go func() {
+						defer wg.Done()
+						for i := g; i < b.N; i += goroutines {
+							counter.add(1)
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0017_concurrency lesson: fix data races.
+//
+// Replace the TODOs and run "little-engine check 0017" to check your work.
+package exercises
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Balance starts n goroutines that increment a counter and n goroutines that
+// decrement it, and returns the counter once all of them are done.
+//
+// TODO: Balance has a data race and rarely returns 0. Fix it with a
+// sync.Mutex or a channel, as shown in the lesson.
+func Balance(n int) int {
+	counter := 0
+	var wg sync.WaitGroup
+	wg.Add(2 * n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			v := counter
+			runtime.Gosched()
+			counter = v + 1
+		}()
+		go func() {
+			defer wg.Done()
+			v := counter
+			runtime.Gosched()
+			counter = v - 1
+		}()
+	}
+	wg.Wait()
+	return counter
+}
@@ -0,0 +1,20 @@
+//go:build checker
+
+package exercises
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestBalance(t *testing.T) {
+	checker.Hint(t, "reading counter, yielding and writing it back must happen in one critical section")
+	leakcheck.Check(t)
+	for i := 0; i < 20; i++ {
+		if got := Balance(100); got != 0 {
+			t.Fatalf("Balance(100) = %d, want 0", got)
+		}
+	}
+}
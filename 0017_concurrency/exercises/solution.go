@@ -0,0 +1,35 @@
+//go:build solution
+
+package exercises
+
+import (
+	"runtime"
+	"sync"
+)
+
+func Balance(n int) int {
+	counter := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(2 * n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			v := counter
+			runtime.Gosched()
+			counter = v + 1
+		}()
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			v := counter
+			runtime.Gosched()
+			counter = v - 1
+		}()
+	}
+	wg.Wait()
+	return counter
+}
@@ -0,0 +1,25 @@
+//go:build ignore
+
+// Two workers that each close the done channel when they finish.
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Println("worker", i, "finished")
+			close(done) // the second worker closes it again
+		}()
+	}
+	wg.Wait()
+	<-done
+	fmt.Println("all workers finished")
+}
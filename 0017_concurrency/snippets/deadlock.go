@@ -0,0 +1,12 @@
+//go:build ignore
+
+// Sending on an unbuffered channel that no goroutine receives from.
+package main
+
+import "fmt"
+
+func main() {
+	ch := make(chan int)
+	ch <- 10 // waits for a receiver forever
+	fmt.Println("received", <-ch)
+}
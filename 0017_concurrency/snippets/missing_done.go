@@ -0,0 +1,25 @@
+//go:build ignore
+
+// A WaitGroup waiting for a goroutine that returns without calling Done.
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			if i == 1 {
+				return // forgot wg.Done()
+			}
+			defer wg.Done()
+			fmt.Println("task", i, "done")
+		}()
+	}
+	wg.Wait()
+	fmt.Println("all tasks done")
+}
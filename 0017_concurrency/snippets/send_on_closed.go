@@ -0,0 +1,16 @@
+//go:build ignore
+
+// Sending on a channel after closing it.
+package main
+
+import "fmt"
+
+func main() {
+	ch := make(chan int, 3)
+	ch <- 1
+	close(ch)
+	ch <- 2 // panics
+	for v := range ch {
+		fmt.Println("received", v)
+	}
+}
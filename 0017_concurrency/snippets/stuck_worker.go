@@ -0,0 +1,25 @@
+//go:build ignore
+
+// A worker waiting for a result that never comes while a heartbeat keeps
+// the program alive, so the runtime can't tell it's stuck.
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	go func() {
+		for range time.Tick(100 * time.Millisecond) {
+			// report that the program is alive
+		}
+	}()
+	results := make(chan string)
+	go func() {
+		// the result is computed but never sent
+		_ = "result"
+	}()
+	fmt.Println("waiting for the result")
+	fmt.Println(<-results)
+}
@@ -0,0 +1,8 @@
+// Command sync_primitives runs the 0018_sync_primitives lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0018_sync_primitives"
+
+func main() {
+	primitives.Run()
+}
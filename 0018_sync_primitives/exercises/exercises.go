@@ -0,0 +1,28 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0018_sync_primitives lesson:
+// run work concurrently with a limit and fail fast.
+//
+// Replace the TODOs and run "little-engine check 0018" to check your work.
+package exercises
+
+import "context"
+
+// FetchAll calls fetch for every key, with up to limit calls running at the
+// same time, and returns the results in the order of keys. The first error
+// is returned and cancels the context of the calls still running.
+//
+// TODO: FetchAll calls fetch for one key at a time and keeps going after an
+// error. Run the calls on a syncx.Group with a limit instead.
+func FetchAll(ctx context.Context, keys []string, limit int, fetch func(ctx context.Context, key string) (string, error)) ([]string, error) {
+	results := make([]string, len(keys))
+	var first error
+	for i, key := range keys {
+		r, err := fetch(ctx, key)
+		if err != nil && first == nil {
+			first = err
+		}
+		results[i] = r
+	}
+	return results, first
+}
@@ -0,0 +1,79 @@
+//go:build checker
+
+package exercises
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestFetchAllConcurrently(t *testing.T) {
+	checker.Hint(t, "start the calls with g.Go after g.SetLimit(limit), and wait for them with g.Wait")
+	leakcheck.Check(t)
+	var (
+		mu             sync.Mutex
+		running, peak  int
+		reachedLimit   = make(chan struct{})
+		closeReachOnce sync.Once
+	)
+	keys := []string{"a", "b", "c", "d", "e", "f", "g"}
+	got, err := FetchAll(context.Background(), keys, 3, func(ctx context.Context, key string) (string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		if running == 3 {
+			closeReachOnce.Do(func() { close(reachedLimit) })
+		}
+		mu.Unlock()
+		// the calls wait until three of them run at the same time
+		select {
+		case <-reachedLimit:
+		case <-time.After(500 * time.Millisecond):
+		}
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return strings.ToUpper(key), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "C", "D", "E", "F", "G"}; !slices.Equal(got, want) {
+		t.Errorf("FetchAll = %v, want %v", got, want)
+	}
+	if peak != 3 {
+		t.Errorf("%d calls ran at the same time, want 3", peak)
+	}
+}
+
+func TestFetchAllFailsFast(t *testing.T) {
+	checker.Hint(t, "the context returned by syncx.WithContext is cancelled on the first error, pass it to fetch")
+	leakcheck.Check(t)
+	errDown := errors.New("service down")
+	start := time.Now()
+	_, err := FetchAll(context.Background(), []string{"a", "b", "c"}, 3, func(ctx context.Context, key string) (string, error) {
+		if key == "b" {
+			return "", errDown
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(2 * time.Second):
+			return key, nil
+		}
+	})
+	if err != errDown {
+		t.Errorf("FetchAll returned %v, want the first error %v", err, errDown)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("FetchAll took %v after the first error", d.Round(100*time.Millisecond))
+	}
+}
@@ -0,0 +1,23 @@
+//go:build solution
+
+package exercises
+
+import (
+	"context"
+
+	"github.com/ssukruth/little-engine-go/pkg/syncx"
+)
+
+func FetchAll(ctx context.Context, keys []string, limit int, fetch func(ctx context.Context, key string) (string, error)) ([]string, error) {
+	results := make([]string, len(keys))
+	g, ctx := syncx.WithContext(ctx)
+	g.SetLimit(limit)
+	for i, key := range keys {
+		g.Go(func() error {
+			r, err := fetch(ctx, key)
+			results[i] = r
+			return err
+		})
+	}
+	return results, g.Wait()
+}
@@ -0,0 +1,176 @@
+// Package primitives is the lesson on one-time initialization, semaphores
+// and groups of goroutines that fail together.
+package primitives
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/syncx"
+)
+
+// Config is what the examples load once.
+type Config struct {
+	Workers int
+}
+
+// loadConfig stands in for reading a file or calling a service, which we
+// want to happen only once however many goroutines need the config.
+func loadConfig() Config {
+	fmt.Println("loading config")
+	return Config{Workers: 3}
+}
+
+func Run() {
+	// The 0017 lesson waits for goroutines with a WaitGroup and protects
+	// shared data with a Mutex. The sync package and pkg/syncx have a few
+	// more primitives for problems that come up all the time.
+
+	// sync.Once runs a function exactly once, however many goroutines call
+	// Do and however often. Goroutines calling Do while the function runs
+	// wait for it to return, so all of them see what it did.
+	var (
+		once sync.Once
+		cfg  Config
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			once.Do(func() { cfg = loadConfig() })
+		}()
+	}
+	wg.Wait()
+	fmt.Println("workers:", cfg.Workers)
+	fmt.Println()
+
+	// sync.OnceValue wraps a function returning a value in one that calls
+	// it the first time and returns the same value ever after. There's no
+	// Once and no variable to keep next to each other anymore.
+	config := sync.OnceValue(loadConfig)
+	fmt.Println("workers:", config().Workers)
+	fmt.Println("workers again:", config().Workers)
+	fmt.Println()
+
+	// sync.OnceValues does the same for functions returning a value and an
+	// error. The error is kept as well, so a failure is final: a config
+	// server that's down on the first call stays down for the program.
+	attempts := 0
+	connect := func() (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", errors.New("connection refused")
+		}
+		return "connected", nil
+	}
+	conn := sync.OnceValues(connect)
+	for i := 0; i < 2; i++ {
+		c, err := conn()
+		fmt.Printf("OnceValues: %q %v\n", c, err)
+	}
+	// syncx.Lazy keeps the value but not the error, the next Get tries
+	// again.
+	attempts = 0
+	lazy := syncx.NewLazy(connect)
+	for i := 0; i < 2; i++ {
+		c, err := lazy.Get()
+		fmt.Printf("Lazy: %q %v\n", c, err)
+	}
+	fmt.Println()
+
+	// A semaphore limits how much work runs at the same time. A buffered
+	// channel of n slots is a semaphore where every job has the same
+	// weight. syncx.Semaphore lets jobs take a weight of their own, e.g.
+	// the memory they need: here at most 4 units are in use at any time,
+	// a big job of 3 units can only run alongside one small job.
+	ctx := context.Background()
+	sem := syncx.NewSemaphore(4)
+	var (
+		mu          sync.Mutex
+		inUse, peak int64
+	)
+	jobs := []int64{1, 3, 1, 1, 3, 2, 1}
+	for _, weight := range jobs {
+		// Acquire blocks until the weight is available
+		if err := sem.Acquire(ctx, weight); err != nil {
+			fmt.Println("acquire:", err)
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(weight)
+			mu.Lock()
+			inUse += weight
+			peak = max(peak, inUse)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			inUse -= weight
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	fmt.Println("ran", len(jobs), "jobs, never more than 4 units at once:", peak <= 4)
+	fmt.Println()
+
+	// A Group runs functions on goroutines like a WaitGroup, but also
+	// collects their errors. The first error cancels the context of the
+	// group, so the other functions can stop early instead of finishing
+	// work nobody will use, and Wait returns it.
+	g, gctx := syncx.WithContext(ctx)
+	results := make([]string, 3)
+	for i, name := range []string{"users", "orders", "invoices"} {
+		g.Go(func() error {
+			if name == "orders" {
+				return fmt.Errorf("fetching %s: service unavailable", name)
+			}
+			select {
+			case <-time.After(time.Minute):
+				results[i] = name + ": fetched"
+				return nil
+			case <-gctx.Done():
+				results[i] = name + ": " + gctx.Err().Error()
+				return gctx.Err()
+			}
+		})
+	}
+	err := g.Wait()
+	fmt.Println("group error:", err)
+	sort.Strings(results)
+	for _, r := range results {
+		if r != "" {
+			fmt.Println(r)
+		}
+	}
+	fmt.Println()
+
+	// SetLimit caps the number of functions running at once; Go waits
+	// for a free slot. That's the semaphore and the group in one.
+	var limited syncx.Group
+	limited.SetLimit(2)
+	running, most := 0, 0
+	for i := 0; i < 6; i++ {
+		limited.Go(func() error {
+			mu.Lock()
+			running++
+			most = max(most, running)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := limited.Wait(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("never more than 2 at once:", most <= 2)
+	fmt.Println()
+}
@@ -0,0 +1,8 @@
+// Command rwmutex_cond runs the 0019_rwmutex_cond lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0019_rwmutex_cond"
+
+func main() {
+	rwcond.Run()
+}
@@ -0,0 +1,44 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0019_rwmutex_cond lesson:
+// wake every waiting goroutine with a condition variable.
+//
+// Replace the TODOs and run "little-engine check 0019" to check your work.
+package exercises
+
+import "sync"
+
+// Gate keeps goroutines waiting until it is opened. Once open it stays
+// open. Gates are created with NewGate.
+type Gate struct {
+	mu     sync.Mutex
+	opened sync.Cond
+	open   bool
+}
+
+// NewGate returns a closed gate.
+func NewGate() *Gate {
+	g := new(Gate)
+	g.opened.L = &g.mu
+	return g
+}
+
+// Wait blocks until the gate is open.
+func (g *Gate) Wait() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for !g.open {
+		g.opened.Wait()
+	}
+}
+
+// Open opens the gate and lets every waiting goroutine through.
+//
+// TODO: Signal wakes only one of the goroutines waiting in Wait. Wake all
+// of them.
+func (g *Gate) Open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.open = true
+	g.opened.Signal()
+}
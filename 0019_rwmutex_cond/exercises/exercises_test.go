@@ -0,0 +1,64 @@
+//go:build checker
+
+package exercises
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestGateOpensForEveryone(t *testing.T) {
+	checker.Hint(t, "Broadcast wakes every goroutine waiting on a sync.Cond, Signal only one")
+	g := NewGate()
+	var (
+		passed sync.WaitGroup
+		mu     sync.Mutex
+		count  int
+	)
+	for i := 0; i < 5; i++ {
+		passed.Add(1)
+		go func() {
+			defer passed.Done()
+			g.Wait()
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}()
+	}
+	// give the goroutines time to start waiting
+	time.Sleep(50 * time.Millisecond)
+	g.Open()
+	done := make(chan struct{})
+	go func() {
+		passed.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		mu.Lock()
+		defer mu.Unlock()
+		t.Fatalf("%d of 5 waiting goroutines got through the open gate", count)
+	}
+}
+
+func TestOpenGateDoesNotBlock(t *testing.T) {
+	checker.Hint(t, "Wait only waits while the gate is closed")
+	leakcheck.Check(t)
+	g := NewGate()
+	g.Open()
+	done := make(chan struct{})
+	go func() {
+		g.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait blocked on an open gate")
+	}
+}
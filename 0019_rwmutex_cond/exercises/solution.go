@@ -0,0 +1,32 @@
+//go:build solution
+
+package exercises
+
+import "sync"
+
+type Gate struct {
+	mu     sync.Mutex
+	opened sync.Cond
+	open   bool
+}
+
+func NewGate() *Gate {
+	g := new(Gate)
+	g.opened.L = &g.mu
+	return g
+}
+
+func (g *Gate) Wait() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for !g.open {
+		g.opened.Wait()
+	}
+}
+
+func (g *Gate) Open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.open = true
+	g.opened.Broadcast()
+}
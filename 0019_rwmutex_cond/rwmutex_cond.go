@@ -0,0 +1,276 @@
+// Package rwcond is the lesson on read-write locks and condition variables:
+// a cache that many goroutines read at the same time and a bounded queue
+// whose goroutines wait for room or for items.
+package rwcond
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Cache is a map that many goroutines read and few write. Reads share the
+// lock and run at the same time, writes take it for themselves. A Cache
+// declared without NewCache has no map yet, and panics on the first Set.
+type Cache[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+// NewCache returns an empty cache.
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{m: make(map[K]V)}
+}
+
+// Get returns the value cached for k.
+func (c *Cache[K, V]) Get(k K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.m[k]
+	return v, ok
+}
+
+// Set caches v for k.
+func (c *Cache[K, V]) Set(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[k] = v
+}
+
+// GetOrLoad returns the value cached for k, calling load to compute and
+// cache it on a miss. Hits only take the read lock. On a miss the write
+// lock is taken and the map checked again, since another goroutine may
+// have loaded k between the two locks.
+func (c *Cache[K, V]) GetOrLoad(k K, load func(K) V) V {
+	if v, ok := c.Get(k); ok {
+		return v
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.m[k]; ok {
+		return v
+	}
+	v := load(k)
+	c.m[k] = v
+	return v
+}
+
+// Len returns the number of cached values.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.m)
+}
+
+// ErrClosed is returned by Put on a closed queue.
+var ErrClosed = errors.New("queue closed")
+
+// Queue is a first-in, first-out queue of at most a fixed number of items.
+// Put waits while the queue is full and Take while it's empty. A Queue
+// declared without NewQueue has room for nothing, and its conditions have no
+// lock to wait with.
+type Queue[T any] struct {
+	mu       sync.Mutex
+	notEmpty sync.Cond // signalled when an item is put
+	notFull  sync.Cond // signalled when an item is taken
+	items    []T
+	size     int
+	closed   bool
+}
+
+// NewQueue returns an empty queue holding at most size items. It panics if
+// size is less than 1, since a queue without room would block Put forever.
+func NewQueue[T any](size int) *Queue[T] {
+	if size < 1 {
+		panic("rwcond: queue size must be at least 1")
+	}
+	q := &Queue[T]{size: size}
+	q.notEmpty.L = &q.mu
+	q.notFull.L = &q.mu
+	return q
+}
+
+// Put adds v to the back of the queue, waiting for room if it's full. It
+// returns ErrClosed if the queue is closed.
+func (q *Queue[T]) Put(v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) >= q.size && !q.closed {
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.items = append(q.items, v)
+	q.notEmpty.Signal()
+	return nil
+}
+
+// Take removes the item at the front of the queue and returns it, waiting
+// for one if the queue is empty. It returns false once the queue is closed
+// and empty.
+func (q *Queue[T]) Take() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	if len(q.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	v := q.items[0]
+	q.items = q.items[1:]
+	q.notFull.Signal()
+	return v, true
+}
+
+// Close makes Put fail and Take return false once the queue is empty, and
+// wakes every goroutine waiting in either.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+func Run() {
+	// In the 0017 lesson the goroutines incrementing and decrementing n
+	// take mutexLock around n++ and n--, so only one goroutine at a time
+	// is in that critical section. That's needed there because every one
+	// of them writes n. Goroutines that only read a variable don't
+	// interfere with each other, though, only with the ones writing it.
+
+	// sync.RWMutex has two locks in one. RLock and RUnlock take and
+	// release a read lock that any number of goroutines can hold at the
+	// same time. Lock and Unlock take and release the write lock, which
+	// is held by one goroutine and only while nobody holds the read lock.
+	//
+	// The three readers below wait inside the critical section until all
+	// of them are in. With a Mutex the second reader couldn't get in and
+	// the program would deadlock.
+	var (
+		rw     sync.RWMutex
+		inside sync.WaitGroup
+		wg     sync.WaitGroup
+	)
+	inside.Add(3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rw.RLock()
+			defer rw.RUnlock()
+			inside.Done()
+			inside.Wait()
+		}()
+	}
+	wg.Wait()
+	fmt.Println("3 readers held the read lock at the same time")
+
+	// A writer waits until the last reader is out.
+	rw.RLock()
+	fmt.Println("reader: reading")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rw.Lock()
+		defer rw.Unlock()
+		fmt.Println("writer: writing")
+	}()
+	fmt.Println("reader: done")
+	rw.RUnlock()
+	wg.Wait()
+	fmt.Println()
+
+	// Once a writer waits for the lock, new readers wait behind it, so a
+	// steady stream of readers doesn't starve writers. The flip side is
+	// that a goroutine must never take the read lock twice: if a writer
+	// starts waiting in between, the second RLock waits for the writer,
+	// which waits for the first RLock to be released. Deadlock.
+
+	// A read-mostly cache is where RWMutex pays off. Cache takes the
+	// read lock in Get and the write lock in Set. GetOrLoad loads a
+	// missing value once, however many goroutines ask for it at once.
+	regions := NewCache[string, string]()
+	endpoint := func(region string) string {
+		fmt.Println("loading", region)
+		return "https://" + region + ".example.com"
+	}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			regions.GetOrLoad("eu-west", endpoint)
+		}()
+	}
+	wg.Wait()
+	url, ok := regions.Get("eu-west")
+	fmt.Println("eu-west:", url, ok)
+	_, ok = regions.Get("us-east")
+	fmt.Println("us-east cached?", ok)
+	fmt.Println("cached regions:", regions.Len())
+	fmt.Println()
+
+	// Whether RWMutex is faster than Mutex depends on how much the readers
+	// overlap. It does more bookkeeping per lock, so with few goroutines,
+	// short critical sections or many writes a Mutex wins. Benchmark your
+	// own mix with
+	// go test -run '^$' -bench Cache ./0019_rwmutex_cond
+
+	// Sometimes a goroutine must wait until the shared data is in some
+	// state, like a queue having an item. Checking in a loop burns CPU,
+	// and the data may change right after the check. sync.Cond solves
+	// both. Wait unlocks the mutex the Cond was created with, sleeps
+	// until another goroutine calls Signal or Broadcast and locks the
+	// mutex again before returning. It's called in a loop because the
+	// state may have changed again before the woken goroutine got the
+	// lock.
+	//
+	// Queue waits on notFull in Put and on notEmpty in Take. Putting an
+	// item makes room for one Take, so Put wakes one waiter with Signal,
+	// and Take does the same for Put. The producer below puts 5 items in
+	// a queue of 2 and waits every time it's full.
+	q := NewQueue[int](2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 5; i++ {
+			q.Put(i)
+		}
+		q.Close()
+	}()
+	for {
+		v, ok := q.Take()
+		if !ok {
+			break
+		}
+		fmt.Println("took", v)
+	}
+	wg.Wait()
+	fmt.Println()
+
+	// Closing changes what every waiter is waiting for, so Close wakes all
+	// of them with Broadcast. With Signal only one of the consumers below
+	// would return, and the others would wait forever.
+	q = NewQueue[int](2)
+	var woken sync.WaitGroup
+	stopped := 0
+	var mu sync.Mutex
+	for i := 0; i < 3; i++ {
+		woken.Add(1)
+		go func() {
+			defer woken.Done()
+			if _, ok := q.Take(); !ok {
+				mu.Lock()
+				stopped++
+				mu.Unlock()
+			}
+		}()
+	}
+	q.Close()
+	woken.Wait()
+	fmt.Println("consumers stopped by Close:", stopped)
+	fmt.Println("put after Close:", q.Put(1))
+}
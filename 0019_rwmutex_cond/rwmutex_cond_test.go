@@ -0,0 +1,175 @@
+package rwcond
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestCacheGetOrLoad(t *testing.T) {
+	leakcheck.Check(t)
+	c := NewCache[int, int]()
+	var loads atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v := c.GetOrLoad(i%5, func(k int) int { loads.Add(1); return k * k }); v != (i%5)*(i%5) {
+				t.Errorf("GetOrLoad(%d) = %d", i%5, v)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := loads.Load(); n != 5 {
+		t.Errorf("loaded %d times, want once per key", n)
+	}
+	if n := c.Len(); n != 5 {
+		t.Errorf("Len() = %d, want 5", n)
+	}
+}
+
+func TestQueueWaitsWhenFull(t *testing.T) {
+	leakcheck.Check(t)
+	q := NewQueue[int](1)
+	q.Put(1)
+	put := make(chan error)
+	go func() { put <- q.Put(2) }()
+	select {
+	case <-put:
+		t.Fatal("Put returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if v, _ := q.Take(); v != 1 {
+		t.Errorf("Take() = %d, want 1", v)
+	}
+	if err := <-put; err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := q.Take(); v != 2 {
+		t.Errorf("Take() = %d, want 2", v)
+	}
+}
+
+func TestNewQueueRejectsSizeWithoutRoom(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewQueue(%d) didn't panic", size)
+				}
+			}()
+			NewQueue[int](size)
+		}()
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	leakcheck.Check(t)
+	q := NewQueue[int](1)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			q.Take()
+		}()
+		go func() {
+			defer wg.Done()
+			q.Put(i)
+		}()
+	}
+	// one Take and one Put may succeed, the others wait until Close
+	time.Sleep(50 * time.Millisecond)
+	q.Close()
+	wg.Wait()
+	if err := q.Put(1); err != ErrClosed {
+		t.Errorf("Put after Close returned %v, want ErrClosed", err)
+	}
+}
+
+func TestQueueDrainsAfterClose(t *testing.T) {
+	q := NewQueue[string](2)
+	q.Put("a")
+	q.Put("b")
+	q.Close()
+	for _, want := range []string{"a", "b"} {
+		if v, ok := q.Take(); !ok || v != want {
+			t.Errorf("Take() = %q, %v, want %q, true", v, ok, want)
+		}
+	}
+	if _, ok := q.Take(); ok {
+		t.Error("Take on a closed, empty queue returned ok")
+	}
+}
+
+// mutexCache is Cache with a Mutex, which it's benchmarked against.
+type mutexCache struct {
+	mu sync.Mutex
+	m  map[int]int
+}
+
+func (c *mutexCache) Get(k int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.m[k]
+	return v, ok
+}
+
+func (c *mutexCache) Set(k, v int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[k] = v
+}
+
+// BenchmarkCache compares Cache with mutexCache for different shares of
+// writes and numbers of goroutines. RWMutex wins when reads dominate and
+// run in parallel, which takes more than one CPU: with GOMAXPROCS=1 only one
+// goroutine runs at a time and the Mutex is always faster.
+func BenchmarkCache(b *testing.B) {
+	const keys = 1024
+	type cache interface {
+		Get(int) (int, bool)
+		Set(int, int)
+	}
+	caches := []struct {
+		name string
+		new  func() cache
+	}{
+		{"rwmutex", func() cache { return NewCache[int, int]() }},
+		{"mutex", func() cache { return &mutexCache{m: make(map[int]int)} }},
+	}
+	for _, writes := range []int{0, 1, 10, 50} {
+		for _, goroutines := range []int{1, 4, 16} {
+			for _, c := range caches {
+				name := fmt.Sprintf("writes=%d%%/goroutines=%d/%s", writes, goroutines, c.name)
+				b.Run(name, func(b *testing.B) {
+					cache := c.new()
+					for k := 0; k < keys; k++ {
+						cache.Set(k, k)
+					}
+					var wg sync.WaitGroup
+					wg.Add(goroutines)
+					b.ResetTimer()
+					for g := 0; g < goroutines; g++ {
+						go func() {
+							defer wg.Done()
+							for i := g; i < b.N; i += goroutines {
+								if i%100 < writes {
+									cache.Set(i%keys, i)
+								} else {
+									cache.Get(i % keys)
+								}
+							}
+						}()
+					}
+					wg.Wait()
+				})
+			}
+		}
+	}
+}
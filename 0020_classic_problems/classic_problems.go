@@ -0,0 +1,704 @@
+// Package classic is the lesson on the classic problems of concurrent
+// programming: the dining philosophers, producers and consumers, readers
+// and writers, and the sleeping barber. Every problem comes with a correct
+// solution and a broken one, and records enough to check its invariants.
+package classic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// The broken solutions take a gap function, which they call in the window
+// where their bug strikes. A gap that waits for another goroutine makes the
+// bug happen every time instead of once in a blue moon, so the lesson and
+// its tests can show it.
+
+// Table is a round table of philosophers with a fork between each two
+// neighbours. A philosopher needs both of their forks to eat.
+type Table struct {
+	// forks[i] is the fork to the left of philosopher i, held by whoever
+	// took the token out of it
+	forks []chan struct{}
+
+	mu      sync.Mutex
+	eating  []bool
+	meals   []int
+	clashes int
+}
+
+// NewTable returns a table of n philosophers who haven't eaten yet. It
+// panics if n is less than 2: a single philosopher has one fork on both
+// sides, and would wait for it while holding it.
+func NewTable(n int) *Table {
+	if n < 2 {
+		panic("classic: a table needs at least 2 philosophers")
+	}
+	t := &Table{forks: make([]chan struct{}, n), eating: make([]bool, n), meals: make([]int, n)}
+	for i := range t.forks {
+		t.forks[i] = make(chan struct{}, 1)
+		t.forks[i] <- struct{}{}
+	}
+	return t
+}
+
+// Meals returns the number of meals each philosopher ate.
+func (t *Table) Meals() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]int(nil), t.meals...)
+}
+
+// Clashes returns how often a philosopher started eating while a
+// neighbour was eating, which the forks should make impossible.
+func (t *Table) Clashes() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.clashes
+}
+
+// Dine has every philosopher eat meals times. Each of them picks up the
+// lower numbered of their two forks first. The last philosopher's forks are
+// n-1 and 0, so they reach right first while everybody else reaches left,
+// and the circle of philosophers each holding one fork and waiting for the
+// next can't close: somebody always gets both forks. Dine returns ctx.Err()
+// if ctx is done before everybody ate.
+func (t *Table) Dine(ctx context.Context, meals int) error {
+	return t.dine(ctx, meals, t.lowerFirst, nil)
+}
+
+// DineLeftFirst is the broken solution: every philosopher picks up the fork
+// on their left first. If all of them do at the same time, each holds one
+// fork and waits for the one their neighbour holds, forever. gap is called
+// after a philosopher picked up the left fork. DineLeftFirst returns
+// ctx.Err() when ctx is done before everybody ate.
+func (t *Table) DineLeftFirst(ctx context.Context, meals int, gap func()) error {
+	return t.dine(ctx, meals, t.leftFirst, gap)
+}
+
+func (t *Table) lowerFirst(p int) (first, second int) {
+	left, right := t.leftFirst(p)
+	return min(left, right), max(left, right)
+}
+
+func (t *Table) leftFirst(p int) (first, second int) {
+	return p, (p + 1) % len(t.forks)
+}
+
+func (t *Table) dine(ctx context.Context, meals int, forks func(p int) (first, second int), gap func()) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(t.forks))
+	for p := range t.forks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first, second := forks(p)
+			for i := 0; i < meals; i++ {
+				if err := t.take(ctx, first); err != nil {
+					errs[p] = err
+					return
+				}
+				if gap != nil {
+					gap()
+				}
+				if err := t.take(ctx, second); err != nil {
+					t.put(first)
+					errs[p] = err
+					return
+				}
+				t.eat(p)
+				t.put(second)
+				t.put(first)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (t *Table) take(ctx context.Context, fork int) error {
+	select {
+	case <-t.forks[fork]:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Table) put(fork int) {
+	t.forks[fork] <- struct{}{}
+}
+
+// eat records a meal of philosopher p and whether a neighbour was eating.
+func (t *Table) eat(p int) {
+	n := len(t.forks)
+	t.mu.Lock()
+	if t.eating[(p+n-1)%n] || t.eating[(p+1)%n] {
+		t.clashes++
+	}
+	t.eating[p] = true
+	t.meals[p]++
+	t.mu.Unlock()
+	// give the neighbours a chance to clash
+	runtime.Gosched()
+	t.mu.Lock()
+	t.eating[p] = false
+	t.mu.Unlock()
+}
+
+// Buffer is a buffer between producers and consumers.
+type Buffer interface {
+	// Put adds an item, waiting while the buffer is full.
+	Put(item int)
+	// Take removes an item, waiting while the buffer is empty.
+	Take() int
+	// Peak returns the most items the buffer ever held.
+	Peak() int
+}
+
+// BoundedBuffer is the textbook solution of the producer-consumer problem
+// with two counting semaphores: free holds a token for every free slot and
+// used one for every used slot. Put takes a free token before adding an
+// item, so it waits while the buffer is full, and Take takes a used token
+// before removing one. The mutex only guards the slice.
+//
+// In Go the buffered channel make(chan int, size) is exactly this, built
+// in. BoundedBuffer shows what it does.
+type BoundedBuffer struct {
+	free, used chan struct{}
+	mu         sync.Mutex
+	items      []int
+	peak       int
+}
+
+// NewBoundedBuffer returns an empty buffer of size slots. It panics if size
+// is less than 1.
+func NewBoundedBuffer(size int) *BoundedBuffer {
+	if size < 1 {
+		panic("classic: buffer size must be at least 1")
+	}
+	b := &BoundedBuffer{free: make(chan struct{}, size), used: make(chan struct{}, size)}
+	for i := 0; i < size; i++ {
+		b.free <- struct{}{}
+	}
+	return b
+}
+
+// Put adds item, waiting for a free slot.
+func (b *BoundedBuffer) Put(item int) {
+	<-b.free
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	b.peak = max(b.peak, len(b.items))
+	b.mu.Unlock()
+	b.used <- struct{}{}
+}
+
+// Take removes the oldest item, waiting for one.
+func (b *BoundedBuffer) Take() int {
+	<-b.used
+	b.mu.Lock()
+	item := b.items[0]
+	b.items = b.items[1:]
+	b.mu.Unlock()
+	b.free <- struct{}{}
+	return item
+}
+
+// Peak returns the most items the buffer ever held.
+func (b *BoundedBuffer) Peak() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// CheckThenPutBuffer is the broken solution. Put checks that the buffer
+// has room and then adds the item, locking the mutex for each step but not
+// across both. Two producers can see the last free slot and both fill it.
+// Gap is called between the check and the put.
+type CheckThenPutBuffer struct {
+	Size int
+	Gap  func()
+
+	mu    sync.Mutex
+	items []int
+	peak  int
+}
+
+// Put adds item once it saw a free slot, which may be gone by then.
+func (b *CheckThenPutBuffer) Put(item int) {
+	for {
+		b.mu.Lock()
+		full := len(b.items) >= b.Size
+		b.mu.Unlock()
+		if !full {
+			break
+		}
+		runtime.Gosched()
+	}
+	if b.Gap != nil {
+		b.Gap()
+	}
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	b.peak = max(b.peak, len(b.items))
+	b.mu.Unlock()
+}
+
+// Take removes the oldest item, waiting for one.
+func (b *CheckThenPutBuffer) Take() int {
+	for {
+		b.mu.Lock()
+		if len(b.items) > 0 {
+			item := b.items[0]
+			b.items = b.items[1:]
+			b.mu.Unlock()
+			return item
+		}
+		b.mu.Unlock()
+		runtime.Gosched()
+	}
+}
+
+// Peak returns the most items the buffer ever held, more than Size if
+// producers overflowed it.
+func (b *CheckThenPutBuffer) Peak() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// ProduceConsume puts items 1 to perProducer from each of the producers
+// into b and takes them out with as many consumers, and returns the sum of
+// what the consumers took.
+func ProduceConsume(b Buffer, producers, perProducer int) int {
+	var wg sync.WaitGroup
+	sums := make([]int, producers)
+	for p := 0; p < producers; p++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= perProducer; i++ {
+				b.Put(i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				sums[p] += b.Take()
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for _, s := range sums {
+		total += s
+	}
+	return total
+}
+
+// Document is shared by readers and writers. Any number of readers may
+// read it at the same time, but a writer needs it to itself. It records
+// every time that rule was broken.
+type Document struct {
+	mu        sync.Mutex
+	readers   int
+	writers   int
+	conflicts int
+}
+
+func (d *Document) enter(writer bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.writers > 0 || (writer && d.readers > 0) {
+		d.conflicts++
+	}
+	if writer {
+		d.writers++
+	} else {
+		d.readers++
+	}
+}
+
+func (d *Document) leave(writer bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if writer {
+		d.writers--
+	} else {
+		d.readers--
+	}
+}
+
+// Conflicts returns how often a writer was in the document together with a
+// reader or another writer.
+func (d *Document) Conflicts() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.conflicts
+}
+
+// Library solves the readers-writers problem with a sync.RWMutex. Once a
+// writer waits for the lock, readers arriving after it wait too, so a
+// steady stream of readers can't starve the writers.
+type Library struct {
+	Document
+	rw sync.RWMutex
+}
+
+// Read calls read while holding the read lock.
+func (l *Library) Read(read func()) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+	l.enter(false)
+	defer l.leave(false)
+	read()
+}
+
+// Write calls write while holding the write lock.
+func (l *Library) Write(write func()) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+	l.enter(true)
+	defer l.leave(true)
+	write()
+}
+
+// CountingLibrary is the broken solution. Readers count themselves in and
+// out, and a writer waits until the count is zero, but nothing keeps a
+// reader from coming in after the writer has looked. Gap is called between
+// the writer's look and its entering the document.
+type CountingLibrary struct {
+	Document
+	Gap func()
+
+	mu      sync.Mutex
+	reading int
+}
+
+// Read calls read counted in as a reader.
+func (l *CountingLibrary) Read(read func()) {
+	l.mu.Lock()
+	l.reading++
+	l.mu.Unlock()
+	l.enter(false)
+	read()
+	l.leave(false)
+	l.mu.Lock()
+	l.reading--
+	l.mu.Unlock()
+}
+
+// Write calls write once it saw no readers, who may have come in by then.
+func (l *CountingLibrary) Write(write func()) {
+	for {
+		l.mu.Lock()
+		n := l.reading
+		l.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	if l.Gap != nil {
+		l.Gap()
+	}
+	l.enter(true)
+	write()
+	l.leave(true)
+}
+
+// Shop is a barbershop with one barber and a waiting room of a few chairs.
+// Customers who find every chair taken leave. The barber sleeps while no
+// customer waits and is woken by the next one.
+//
+// The waiting room is a buffered channel. The barber sleeps by receiving
+// from it and a customer wakes him by sending to it, in a single step, so
+// a customer can't arrive between the barber finding the room empty and
+// falling asleep.
+type Shop struct {
+	waiting chan int
+
+	mu                 sync.Mutex
+	served, turnedAway []int
+}
+
+// NewShop returns a shop with chairs chairs in the waiting room. It panics
+// if chairs is less than 1: without a waiting room every customer who
+// arrives while the barber is busy would be turned away.
+func NewShop(chairs int) *Shop {
+	if chairs < 1 {
+		panic("classic: a shop needs at least 1 chair")
+	}
+	return &Shop{waiting: make(chan int, chairs)}
+}
+
+// Arrive seats the customer in the waiting room and reports whether there
+// was a free chair.
+func (s *Shop) Arrive(customer int) bool {
+	select {
+	case s.waiting <- customer:
+		return true
+	default:
+		s.mu.Lock()
+		s.turnedAway = append(s.turnedAway, customer)
+		s.mu.Unlock()
+		return false
+	}
+}
+
+// Close tells the barber that no more customers come today. He finishes
+// the ones waiting and goes home.
+func (s *Shop) Close() {
+	close(s.waiting)
+}
+
+// Barber cuts the hair of the waiting customers in the order they arrived,
+// sleeping while the room is empty, until the shop is closed and empty.
+func (s *Shop) Barber() {
+	for customer := range s.waiting {
+		s.mu.Lock()
+		s.served = append(s.served, customer)
+		s.mu.Unlock()
+	}
+}
+
+// Served returns the customers who got a haircut, in order.
+func (s *Shop) Served() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.served...)
+}
+
+// TurnedAway returns the customers who found no free chair.
+func (s *Shop) TurnedAway() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.turnedAway...)
+}
+
+// ErrLostWakeup is returned by the broken barber who sleeps while customers
+// wait.
+var ErrLostWakeup = errors.New("the barber is asleep while customers wait")
+
+// FlagShop is the broken solution. The barber looks at the waiting room,
+// and if it is empty sets a flag saying he's asleep and waits to be woken.
+// A customer wakes him only if the flag is set. A customer arriving after
+// the barber looked but before he set the flag doesn't wake him, and both
+// wait for each other: the lost wakeup. Gap is called between the look
+// and setting the flag.
+type FlagShop struct {
+	Chairs int
+	Gap    func()
+
+	mu      sync.Mutex
+	waiting []int
+	asleep  bool
+	wake    chan struct{}
+	served  []int
+}
+
+// NewFlagShop returns a shop with chairs chairs in the waiting room. It
+// panics if chairs is less than 1, which would turn away every customer.
+func NewFlagShop(chairs int) *FlagShop {
+	if chairs < 1 {
+		panic("classic: a shop needs at least 1 chair")
+	}
+	return &FlagShop{Chairs: chairs, wake: make(chan struct{}, 1)}
+}
+
+// Arrive seats the customer in the waiting room, waking the barber if his
+// flag says he's asleep, and reports whether there was a free chair.
+func (s *FlagShop) Arrive(customer int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) >= s.Chairs {
+		return false
+	}
+	s.waiting = append(s.waiting, customer)
+	if s.asleep {
+		s.asleep = false
+		s.wake <- struct{}{}
+	}
+	return true
+}
+
+// Barber serves customers until want of them got a haircut. He returns
+// ErrLostWakeup if ctx is done while he sleeps and customers wait, and
+// ctx.Err() if it is done otherwise.
+func (s *FlagShop) Barber(ctx context.Context, want int) error {
+	for len(s.Served()) < want {
+		s.mu.Lock()
+		empty := len(s.waiting) == 0
+		s.mu.Unlock()
+		if empty {
+			if s.Gap != nil {
+				s.Gap()
+			}
+			s.mu.Lock()
+			s.asleep = true
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+			case <-ctx.Done():
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if len(s.waiting) > 0 {
+					return ErrLostWakeup
+				}
+				return ctx.Err()
+			}
+			continue
+		}
+		s.mu.Lock()
+		s.served = append(s.served, s.waiting[0])
+		s.waiting = s.waiting[1:]
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Served returns the customers who got a haircut, in order.
+func (s *FlagShop) Served() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.served...)
+}
+
+func Run() {
+	// The dining philosophers. Five philosophers sit at a round table
+	// with a fork between each two of them. A philosopher needs both
+	// neighbouring forks to eat, so two neighbours never eat at the same
+	// time. The forks are channels holding one token each: taking the
+	// token picks the fork up, putting it back puts the fork down.
+	ctx := context.Background()
+	table := NewTable(5)
+	if err := table.Dine(ctx, 3); err != nil {
+		fmt.Println("dinner:", err)
+	}
+	fmt.Println("meals eaten:", table.Meals())
+	fmt.Println("neighbours eating at the same time:", table.Clashes())
+
+	// If every philosopher picks up the left fork first, they can all
+	// end up holding their left fork and waiting for their right one,
+	// which the neighbour holds: a deadlock. The gap below lets the
+	// philosophers reach for the second fork only once everybody holds
+	// a first one, which makes the unlucky timing certain. The timeout
+	// ends the wait.
+	var everybodyHoldsOne sync.WaitGroup
+	everybodyHoldsOne.Add(5)
+	gap := func() {
+		everybodyHoldsOne.Done()
+		everybodyHoldsOne.Wait()
+	}
+	tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	err := NewTable(5).DineLeftFirst(tctx, 1, gap)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Println("left fork first: deadlock, every philosopher holds one fork and waits for the other")
+	}
+	fmt.Println()
+
+	// Producers and consumers. Producers put items into a buffer of a
+	// fixed size and consumers take them out. Producers must wait while
+	// the buffer is full, consumers while it's empty.
+	buf := NewBoundedBuffer(2)
+	sum := ProduceConsume(buf, 3, 100)
+	fmt.Println("consumed every item:", sum == 3*(100*101/2))
+	fmt.Println("never more than 2 items in the buffer:", buf.Peak() <= 2)
+
+	// Checking for room and then putting the item in are two steps. Two
+	// producers that both see the last free slot both fill it. The gap
+	// lets both producers check before either of them puts.
+	broken := &CheckThenPutBuffer{Size: 2}
+	broken.Put(0)
+	var bothChecked sync.WaitGroup
+	bothChecked.Add(2)
+	broken.Gap = func() {
+		bothChecked.Done()
+		bothChecked.Wait()
+	}
+	var wg sync.WaitGroup
+	for i := 1; i <= 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			broken.Put(i)
+		}()
+	}
+	wg.Wait()
+	fmt.Println("items in the broken buffer of 2:", broken.Peak())
+	fmt.Println()
+
+	// Readers and writers. Any number of readers may read a document at
+	// the same time, but a writer needs it to itself. That's exactly what
+	// sync.RWMutex provides.
+	lib := new(Library)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%5 == 0 {
+				lib.Write(runtime.Gosched)
+			} else {
+				lib.Read(runtime.Gosched)
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println("writers sharing the document:", lib.Conflicts())
+
+	// A writer that waits for the reader count to drop to zero and then
+	// walks in can meet a reader that came in right after it looked. The
+	// gap lets a reader in at exactly that moment.
+	counting := new(CountingLibrary)
+	readerIn, writerDone := make(chan struct{}), make(chan struct{})
+	counting.Gap = func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counting.Read(func() {
+				close(readerIn)
+				<-writerDone
+			})
+		}()
+		<-readerIn
+	}
+	counting.Write(func() {})
+	close(writerDone)
+	wg.Wait()
+	fmt.Println("writers sharing the broken document:", counting.Conflicts())
+	fmt.Println()
+
+	// The sleeping barber. A barber cuts hair, and sleeps while nobody
+	// waits. Customers take one of 3 chairs in the waiting room, or leave
+	// if all are taken. Here 5 customers arrive before the barber starts.
+	shop := NewShop(3)
+	for c := 1; c <= 5; c++ {
+		shop.Arrive(c)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shop.Barber()
+	}()
+	shop.Close()
+	wg.Wait()
+	fmt.Println("served:", shop.Served(), "turned away:", shop.TurnedAway())
+
+	// A barber who looks at the empty waiting room and only then says
+	// he's asleep misses a customer who arrives in between. The customer
+	// waits to be woken and the barber sleeps: nobody moves anymore.
+	flagShop := NewFlagShop(3)
+	flagShop.Gap = func() {
+		flagShop.Arrive(1)
+	}
+	tctx, cancel = context.WithTimeout(ctx, 100*time.Millisecond)
+	err = flagShop.Barber(tctx, 1)
+	cancel()
+	fmt.Println("flag shop:", err)
+}
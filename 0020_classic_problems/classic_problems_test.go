@@ -0,0 +1,325 @@
+package classic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestDineNeighboursNeverEatTogether(t *testing.T) {
+	leakcheck.Check(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	table := NewTable(5)
+	if err := table.Dine(ctx, 200); err != nil {
+		t.Fatalf("Dine: %v", err)
+	}
+	if n := table.Clashes(); n != 0 {
+		t.Errorf("neighbours ate at the same time %d times", n)
+	}
+	// nobody starved: everybody got every meal
+	for p, n := range table.Meals() {
+		if n != 200 {
+			t.Errorf("philosopher %d ate %d meals, want 200", p, n)
+		}
+	}
+}
+
+func TestNewTableRejectsLonelyPhilosopher(t *testing.T) {
+	for _, n := range []int{1, 0} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewTable(%d) didn't panic", n)
+				}
+			}()
+			NewTable(n)
+		}()
+	}
+}
+
+func TestDineTwoPhilosophers(t *testing.T) {
+	leakcheck.Check(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	table := NewTable(2)
+	if err := table.Dine(ctx, 50); err != nil {
+		t.Fatalf("Dine: %v", err)
+	}
+	if got := table.Meals(); !slices.Equal(got, []int{50, 50}) {
+		t.Errorf("Meals() = %v, want 50 each", got)
+	}
+	if n := table.Clashes(); n != 0 {
+		t.Errorf("neighbours ate at the same time %d times", n)
+	}
+}
+
+func TestDineSurvivesSlowHands(t *testing.T) {
+	leakcheck.Check(t)
+	// with a philosopher holding one fork for a while, the left fork
+	// first solution deadlocks almost every time
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	table := NewTable(5)
+	if err := table.dine(ctx, 20, table.lowerFirst, func() { time.Sleep(100 * time.Microsecond) }); err != nil {
+		t.Fatalf("dine: %v", err)
+	}
+	if n := table.Clashes(); n != 0 {
+		t.Errorf("neighbours ate at the same time %d times", n)
+	}
+}
+
+func TestDineLeftFirstDeadlocks(t *testing.T) {
+	leakcheck.Check(t)
+	var everybodyHoldsOne sync.WaitGroup
+	everybodyHoldsOne.Add(5)
+	gap := func() {
+		everybodyHoldsOne.Done()
+		everybodyHoldsOne.Wait()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	table := NewTable(5)
+	if err := table.DineLeftFirst(ctx, 1, gap); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DineLeftFirst = %v, want a deadlock ended by the timeout", err)
+	}
+	for p, n := range table.Meals() {
+		if n != 0 {
+			t.Errorf("philosopher %d ate %d meals in a deadlock", p, n)
+		}
+	}
+}
+
+func TestBoundedBufferNeverOverflows(t *testing.T) {
+	leakcheck.Check(t)
+	b := NewBoundedBuffer(3)
+	if sum := ProduceConsume(b, 8, 500); sum != 8*(500*501/2) {
+		t.Errorf("consumers took items summing to %d, want %d", sum, 8*(500*501/2))
+	}
+	if p := b.Peak(); p > 3 {
+		t.Errorf("buffer of 3 held %d items", p)
+	}
+}
+
+func TestBoundedBufferKeepsOrder(t *testing.T) {
+	b := NewBoundedBuffer(4)
+	for i := 1; i <= 4; i++ {
+		b.Put(i)
+	}
+	for i := 1; i <= 4; i++ {
+		if got := b.Take(); got != i {
+			t.Fatalf("Take() = %d, want %d", got, i)
+		}
+	}
+}
+
+func TestCheckThenPutBufferOverflows(t *testing.T) {
+	leakcheck.Check(t)
+	b := &CheckThenPutBuffer{Size: 1}
+	var bothChecked sync.WaitGroup
+	bothChecked.Add(2)
+	b.Gap = func() {
+		bothChecked.Done()
+		bothChecked.Wait()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Put(i)
+		}()
+	}
+	wg.Wait()
+	if p := b.Peak(); p != 2 {
+		t.Errorf("buffer of 1 held %d items, want the overflow to 2", p)
+	}
+}
+
+func TestLibraryWritersAlone(t *testing.T) {
+	leakcheck.Check(t)
+	lib := new(Library)
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%4 == 0 {
+				lib.Write(func() { time.Sleep(10 * time.Microsecond) })
+			} else {
+				lib.Read(func() { time.Sleep(10 * time.Microsecond) })
+			}
+		}()
+	}
+	wg.Wait()
+	if n := lib.Conflicts(); n != 0 {
+		t.Errorf("writers shared the document %d times", n)
+	}
+}
+
+func TestLibraryWriterNotStarved(t *testing.T) {
+	leakcheck.Check(t)
+	lib := new(Library)
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	// overlapping readers keep the read lock held all the time
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				lib.Read(func() { time.Sleep(time.Millisecond) })
+			}
+		}()
+	}
+	defer readers.Wait()
+	defer close(stop)
+
+	wrote := make(chan struct{})
+	go lib.Write(func() { close(wrote) })
+	select {
+	case <-wrote:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a stream of readers kept the writer out")
+	}
+}
+
+func TestCountingLibraryLetsReaderIn(t *testing.T) {
+	leakcheck.Check(t)
+	lib := new(CountingLibrary)
+	var wg sync.WaitGroup
+	readerIn, writerDone := make(chan struct{}), make(chan struct{})
+	lib.Gap = func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lib.Read(func() {
+				close(readerIn)
+				<-writerDone
+			})
+		}()
+		<-readerIn
+	}
+	lib.Write(func() {})
+	close(writerDone)
+	wg.Wait()
+	if n := lib.Conflicts(); n != 1 {
+		t.Errorf("Conflicts() = %d, want the writer to have met the reader", n)
+	}
+}
+
+func TestShopServesOrTurnsAwayEveryone(t *testing.T) {
+	leakcheck.Check(t)
+	shop := NewShop(3)
+	var barber sync.WaitGroup
+	barber.Add(1)
+	go func() {
+		defer barber.Done()
+		shop.Barber()
+	}()
+	var customers sync.WaitGroup
+	for c := 0; c < 100; c++ {
+		customers.Add(1)
+		go func() {
+			defer customers.Done()
+			shop.Arrive(c)
+		}()
+	}
+	customers.Wait()
+	shop.Close()
+	barber.Wait()
+
+	served, away := shop.Served(), shop.TurnedAway()
+	all := append(served, away...)
+	slices.Sort(all)
+	for c := 0; c < 100; c++ {
+		if c >= len(all) || all[c] != c {
+			t.Fatalf("served %v and turned away %v, want every customer exactly once", served, away)
+		}
+	}
+	if len(served) == 0 {
+		t.Error("the barber served nobody")
+	}
+}
+
+func TestShopTurnsAwayWhenFull(t *testing.T) {
+	shop := NewShop(2)
+	for c := 1; c <= 4; c++ {
+		if got, want := shop.Arrive(c), c <= 2; got != want {
+			t.Errorf("Arrive(%d) = %v, want %v", c, got, want)
+		}
+	}
+	shop.Close()
+	shop.Barber()
+	if got := shop.Served(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Served() = %v, want [1 2]", got)
+	}
+	if got := shop.TurnedAway(); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("TurnedAway() = %v, want [3 4]", got)
+	}
+}
+
+func TestNewShopRejectsNoChairs(t *testing.T) {
+	for _, chairs := range []int{0, -1} {
+		for name, newShop := range map[string]func(int){
+			"NewShop":     func(n int) { NewShop(n) },
+			"NewFlagShop": func(n int) { NewFlagShop(n) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s(%d) didn't panic", name, chairs)
+					}
+				}()
+				newShop(chairs)
+			}()
+		}
+	}
+}
+
+func TestFlagShopLosesWakeup(t *testing.T) {
+	leakcheck.Check(t)
+	shop := NewFlagShop(3)
+	shop.Gap = func() { shop.Arrive(1) }
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := shop.Barber(ctx, 1); !errors.Is(err, ErrLostWakeup) {
+		t.Fatalf("Barber = %v, want %v", err, ErrLostWakeup)
+	}
+	if n := len(shop.Served()); n != 0 {
+		t.Errorf("served %d customers while asleep", n)
+	}
+}
+
+func TestFlagShopWakesOnArrival(t *testing.T) {
+	leakcheck.Check(t)
+	shop := NewFlagShop(3)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- shop.Barber(ctx, 1) }()
+	// without a customer in the gap the flag works
+	for {
+		shop.mu.Lock()
+		asleep := shop.asleep
+		shop.mu.Unlock()
+		if asleep {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	shop.Arrive(1)
+	if err := <-done; err != nil {
+		t.Fatalf("Barber = %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+// Command classic_problems runs the 0020_classic_problems lesson.
+package main
+
+import "github.com/ssukruth/little-engine-go/0020_classic_problems"
+
+func main() {
+	classic.Run()
+}
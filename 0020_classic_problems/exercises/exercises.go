@@ -0,0 +1,27 @@
+//go:build !solution
+
+// Package exercises holds the exercises of the 0020_classic_problems lesson:
+// seat the dining philosophers so that they can't deadlock, and turn
+// customers away from a full barbershop.
+//
+// Replace the TODOs and run "little-engine check 0020" to check your work.
+package exercises
+
+// ForkOrder returns the forks philosopher p of n picks up, first and second.
+// Fork p lies to the left of philosopher p and fork (p+1)%n to the right.
+//
+// TODO: when every philosopher picks up the left fork first, all of them
+// can hold one fork and wait for the other forever. Pick up the forks in an
+// order that makes this impossible.
+func ForkOrder(p, n int) (first, second int) {
+	return p, (p + 1) % n
+}
+
+// Seat puts customer on a free chair of the waiting room and reports
+// whether there was one.
+//
+// TODO: a customer who finds every chair taken leaves instead of waiting.
+func Seat(chairs chan<- int, customer int) bool {
+	chairs <- customer
+	return true
+}
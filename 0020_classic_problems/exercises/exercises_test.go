@@ -0,0 +1,108 @@
+//go:build checker
+
+package exercises
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestForkOrderUsesNeighbouringForks(t *testing.T) {
+	checker.Hint(t, "a philosopher eats with the forks to their left and right, p and (p+1)%n")
+	for n := 2; n <= 7; n++ {
+		for p := 0; p < n; p++ {
+			first, second := ForkOrder(p, n)
+			left, right := p, (p+1)%n
+			if !(first == left && second == right) && !(first == right && second == left) {
+				t.Fatalf("ForkOrder(%d, %d) = %d, %d, want forks %d and %d", p, n, first, second, left, right)
+			}
+		}
+	}
+}
+
+func TestForkOrderCantDeadlock(t *testing.T) {
+	checker.Hint(t, "if every philosopher reaches for a different fork first, all forks are taken and nobody gets a second one; let one philosopher reach the other way, say for the lower numbered fork first")
+	leakcheck.Check(t)
+	const n = 5
+	forks := make([]chan struct{}, n)
+	for i := range forks {
+		forks[i] = make(chan struct{}, 1)
+		forks[i] <- struct{}{}
+	}
+	take := func(ctx context.Context, fork int) bool {
+		select {
+		case <-forks[fork]:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// every philosopher reaches for the second fork only once everybody
+	// who could pick up their first fork did, the timing that deadlocks
+	var holding sync.WaitGroup
+	holding.Add(n)
+	var wg sync.WaitGroup
+	for p := 0; p < n; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first, second := ForkOrder(p, n)
+			for meal := 0; meal < 3; meal++ {
+				got := false
+				if meal == 0 {
+					select {
+					case <-forks[first]:
+						got = true
+					case <-time.After(50 * time.Millisecond):
+					}
+					holding.Done()
+					holding.Wait()
+				}
+				if !got && !take(ctx, first) {
+					return
+				}
+				if !take(ctx, second) {
+					forks[first] <- struct{}{}
+					return
+				}
+				forks[second] <- struct{}{}
+				forks[first] <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		t.Fatal("the philosophers deadlocked, each holding one fork")
+	}
+}
+
+func TestSeatTurnsAwayWhenFull(t *testing.T) {
+	checker.Hint(t, "a select with a default case sends only if the channel has room")
+	leakcheck.Check(t)
+	chairs := make(chan int, 2)
+	for c := 1; c <= 2; c++ {
+		if !Seat(chairs, c) {
+			t.Fatalf("Seat(%d) = false with a free chair", c)
+		}
+	}
+	seated := make(chan bool, 1)
+	go func() { seated <- Seat(chairs, 3) }()
+	select {
+	case ok := <-seated:
+		if ok {
+			t.Fatal("Seat(3) = true with every chair taken")
+		}
+	case <-time.After(time.Second):
+		<-chairs
+		<-seated
+		t.Fatal("Seat(3) waited for a chair instead of leaving")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build solution
+
+package exercises
+
+func ForkOrder(p, n int) (first, second int) {
+	left, right := p, (p+1)%n
+	return min(left, right), max(left, right)
+}
+
+func Seat(chairs chan<- int, customer int) bool {
+	select {
+	case chairs <- customer:
+		return true
+	default:
+		return false
+	}
+}
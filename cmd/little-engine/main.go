@@ -0,0 +1,518 @@
+// Command little-engine lists, shows and runs the numbered lessons of this
+// repository.
+//
+// Usage:
+//
+//	little-engine [-root dir] list [-tag kind:value]
+//	little-engine [-root dir] syllabus [-tag kind:value]
+//	little-engine [-root dir] validate
+//	little-engine [-root dir] show <NNNN>
+//	little-engine [-root dir] run [-input file | -interactive] <NNNN>
+//	little-engine [-root dir] run [-input file] --all
+//	little-engine [-root dir] check <NNNN>
+//	little-engine [-root dir] diagnose [-timeout d] [-trace] <NNNN> [snippet]
+//	little-engine [-root dir] progress
+//	little-engine [-root dir] serve [-addr host:port]
+//	little-engine [-root dir] book [-format md|html] [-out dir]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/book"
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/playground"
+	"github.com/ssukruth/little-engine-go/internal/progress"
+	"github.com/ssukruth/little-engine-go/internal/runner"
+	"github.com/ssukruth/little-engine-go/pkg/input"
+)
+
+const usage = `usage: little-engine [-root dir] <command> [arguments]
+
+commands:
+  list             list the lessons, -tag filters them by topic
+  syllabus         print the objectives and prerequisites of the lessons
+  validate         check the lesson manifests
+  show <NNNN>      print the source of a lesson
+  run <NNNN>       run a lesson, -input answers its prompts from a file and
+                   -interactive edits answers with history
+  run --all        run every lesson and report pass/fail
+  check <NNNN>     check your solutions to the exercises of a lesson
+  diagnose <NNNN>  list the snippets of a lesson that fail on purpose, or run
+                   one and explain why it deadlocks, panics or hangs
+  progress         show the lessons you've completed and what to do next
+  serve            serve an offline playground for the lessons
+  book             export the lessons and their output as a markdown or html book
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("little-engine", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	root := fs.String("root", ".", "path inside the little-engine-go repository")
+	defaultProgress, _ := progress.DefaultPath()
+	progressPath := fs.String("progress", defaultProgress, "progress state `file`")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	dir, err := lessons.FindRoot(*root)
+	if err != nil {
+		fmt.Fprintln(stderr, "little-engine:", err)
+		return 1
+	}
+	ls, err := lessons.Discover(dir)
+	if err != nil {
+		fmt.Fprintln(stderr, "little-engine:", err)
+		return 1
+	}
+
+	cmd, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "list":
+		err = list(ls, cmdArgs, stdout)
+	case "syllabus":
+		err = syllabus(ls, cmdArgs, stdout)
+	case "validate":
+		err = lessons.Validate(ls)
+		if err == nil {
+			fmt.Fprintf(stdout, "All %d lesson manifests are valid.\n", len(ls))
+		}
+	case "show":
+		err = show(ls, cmdArgs, stdout)
+	case "run":
+		err = runLessons(ls, cmdArgs, stdout, *progressPath)
+	case "check":
+		err = check(ls, cmdArgs, stdout, *progressPath)
+	case "diagnose":
+		err = diagnose(ls, cmdArgs, stdout)
+	case "progress":
+		err = showProgress(ls, stdout, *progressPath)
+	case "serve":
+		err = serve(ls, cmdArgs, stderr)
+	case "book":
+		err = writeBook(ls, cmdArgs, stderr)
+	default:
+		fmt.Fprintf(stderr, "little-engine: unknown command %q\n", cmd)
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "little-engine:", err)
+		return 1
+	}
+	return 0
+}
+
+func list(ls []lessons.Lesson, args []string, w io.Writer) error {
+	ls, err := filterTag(ls, "list", args)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, l := range ls {
+		if l.Manifest == nil {
+			fmt.Fprintf(tw, "%s\t%s\t\t\n", l.ID, l.Name)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", l.ID, l.Name, l.Manifest.Title, l.Manifest.Estimated())
+	}
+	return tw.Flush()
+}
+
+func syllabus(ls []lessons.Lesson, args []string, w io.Writer) error {
+	ls, err := filterTag(ls, "syllabus", args)
+	if err != nil {
+		return err
+	}
+	var total time.Duration
+	for _, l := range ls {
+		m := l.Manifest
+		if m == nil {
+			fmt.Fprintf(w, "%s\n    no %s\n\n", l.Title(), lessons.ManifestFile)
+			continue
+		}
+		total += m.Estimated()
+		fmt.Fprintf(w, "%s %s (%v)\n", l.ID, m.Title, m.Estimated())
+		fmt.Fprintf(w, "    %s\n", m.Summary)
+		if len(m.Prerequisites) > 0 {
+			fmt.Fprintf(w, "    Prerequisites: %s\n", strings.Join(m.Prerequisites, ", "))
+		}
+		fmt.Fprintln(w, "    Objectives:")
+		for _, o := range m.Objectives {
+			fmt.Fprintf(w, "      - %s\n", o)
+		}
+		fmt.Fprintf(w, "    Tags: %s\n\n", strings.Join(m.Tags, ", "))
+	}
+	fmt.Fprintf(w, "%d lessons, %v in total\n", len(ls), total)
+	return nil
+}
+
+// filterTag parses the -tag flag of cmd and returns the lessons having the
+// tag, or all lessons if the flag isn't set.
+func filterTag(ls []lessons.Lesson, cmd string, args []string) ([]lessons.Lesson, error) {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	tag := fs.String("tag", "", "only show lessons with this `tag`, e.g. stdlib:bufio or concept")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("%s takes no arguments", cmd)
+	}
+	if *tag == "" {
+		return ls, nil
+	}
+	var res []lessons.Lesson
+	for _, l := range ls {
+		if l.Manifest != nil && l.Manifest.HasTag(*tag) {
+			res = append(res, l)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no lesson is tagged %s", *tag)
+	}
+	return res, nil
+}
+
+func show(ls []lessons.Lesson, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("show takes exactly one lesson id")
+	}
+	l, err := lessons.Find(ls, args[0])
+	if err != nil {
+		return err
+	}
+	srcs, err := l.Sources()
+	if err != nil {
+		return err
+	}
+	for _, src := range srcs {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if len(srcs) > 1 {
+			fmt.Fprintf(w, "// ==> %s <==\n", src)
+		}
+		w.Write(data)
+	}
+	return nil
+}
+
+func runLessons(ls []lessons.Lesson, args []string, w io.Writer, progressPath string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	all := fs.Bool("all", false, "run every lesson")
+	var src input.Source
+	fs.StringVar(&src.File, "input", "", "read the answers to lesson prompts from `file`, one per line")
+	fs.BoolVar(&src.Interactive, "interactive", false, "edit answers with arrow keys and history")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *all && fs.NArg() == 0:
+	case !*all && fs.NArg() == 1:
+		l, err := lessons.Find(ls, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := warnPrerequisites(ls, l, w, progressPath); err != nil {
+			return err
+		}
+		ls = []lessons.Lesson{l}
+	default:
+		return fmt.Errorf("run takes either one lesson id or --all")
+	}
+	if *all && src.File == "" && !src.Interactive {
+		// don't stop halfway through the lessons to wait for input
+		src.Answers = []string{""}
+	}
+	if src.Interactive {
+		src.HistoryFile = input.DefaultHistoryFile()
+	}
+	stdin, done, err := src.Open(os.Stdin, w)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	var results []runner.Result
+	for _, l := range ls {
+		fmt.Fprintf(w, "=== RUN   %s\n", l.Title())
+		res := runner.Run(l, stdin, w)
+		results = append(results, res)
+		err := updateProgress(progressPath, func(s *progress.State) {
+			s.RecordRun(l.ID, time.Now())
+		})
+		if err != nil {
+			return err
+		}
+		if res.Passed() {
+			fmt.Fprintf(w, "--- PASS: %s (%.2fs)\n", l.Title(), res.Duration.Seconds())
+		} else {
+			fmt.Fprintf(w, "--- FAIL: %s (%.2fs): %v\n", l.Title(), res.Duration.Seconds(), res.Err)
+		}
+	}
+
+	if len(results) == 1 {
+		if !results[0].Passed() {
+			return fmt.Errorf("%s failed", results[0].Lesson.Title())
+		}
+		return nil
+	}
+	var failed []string
+	fmt.Fprintln(w)
+	for _, res := range results {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+			failed = append(failed, res.Lesson.Title())
+		}
+		fmt.Fprintf(w, "%s  %s\n", status, res.Lesson.Title())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d lessons failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func check(ls []lessons.Lesson, args []string, w io.Writer, progressPath string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("check takes exactly one lesson id")
+	}
+	l, err := lessons.Find(ls, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Checking the exercises of %s\n\n", l.Title())
+	rep, err := checker.Run(l.Dir)
+	if err != nil {
+		return err
+	}
+	checker.Print(w, rep)
+	if rep.BuildOutput == "" {
+		results := map[string]bool{}
+		for _, c := range rep.Checks {
+			results[c.Name] = c.Passed
+		}
+		err := updateProgress(progressPath, func(s *progress.State) {
+			s.RecordCheck(l.ID, results, time.Now())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !rep.Passed() {
+		return fmt.Errorf("%s exercises aren't solved yet", l.Title())
+	}
+	return nil
+}
+
+func diagnose(ls []lessons.Lesson, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	watchdog := fs.Duration("timeout", 5*time.Second, "stop the snippet and dump its goroutines after `duration`")
+	trace := fs.Bool("trace", false, "print the goroutine dump of the runtime as well")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 && fs.NArg() != 2 {
+		return fmt.Errorf("diagnose takes a lesson id and optionally a snippet name")
+	}
+	l, err := lessons.Find(ls, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		ss, err := runner.Snippets(l)
+		if err != nil {
+			return err
+		}
+		if len(ss) == 0 {
+			return fmt.Errorf("%s has no snippets", l.Title())
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, s := range ss {
+			fmt.Fprintf(tw, "%s\t%s\n", s.Name, s.Doc)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nRun one with \"little-engine diagnose %s <snippet>\"\n", l.ID)
+		return nil
+	}
+	s, err := runner.FindSnippet(l, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "=== DIAGNOSE %s %s\n%s\n\n", l.Title(), s.Name, runner.Wrap(s.Doc))
+	d, err := runner.Diagnose(context.Background(), s, *watchdog)
+	if err != nil {
+		return err
+	}
+	if d.Output != "" {
+		fmt.Fprintf(w, "Output:\n%s\n", d.Output)
+	}
+	d.Report(w)
+	if *trace && d.Trace != "" {
+		fmt.Fprintf(w, "\nGoroutine dump:\n%s", d.Trace)
+	} else if d.Trace != "" {
+		fmt.Fprintln(w, "\nRun with -trace to see the goroutine dump of the runtime.")
+	}
+	return nil
+}
+
+func showProgress(ls []lessons.Lesson, w io.Writer, progressPath string) error {
+	s, err := progress.Load(progressPath)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "LESSON\tSTATUS\tATTEMPTS\tLAST WORKED")
+	var ids []string
+	for _, l := range ls {
+		ids = append(ids, l.ID)
+		lp, ok := s.Lessons[l.ID]
+		if !ok {
+			fmt.Fprintf(tw, "%s\t%s\t\t\n", l.Title(), "not started")
+			continue
+		}
+		status := "in progress"
+		if lp.Completed {
+			status = "completed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", l.Title(), status, lp.Attempts(), lp.LastWorked.Local().Format("2006-01-02 15:04"))
+		var names []string
+		for name := range lp.Exercises {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			e := lp.Exercises[name]
+			status := "failing"
+			if e.Passed {
+				status = "passed"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t\n", name, status, e.Attempts)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if next := s.Next(ids); next != "" {
+		l, _ := lessons.Find(ls, next)
+		fmt.Fprintf(w, "Next lesson: %s, run \"little-engine run %s\" and \"little-engine check %s\"\n", l.Title(), l.ID, l.ID)
+	} else {
+		fmt.Fprintln(w, "All lessons completed!")
+	}
+	return nil
+}
+
+// updateProgress applies f to the progress state in path and saves it.
+func updateProgress(path string, f func(*progress.State)) error {
+	if path == "" {
+		return nil
+	}
+	s, err := progress.Load(path)
+	if err != nil {
+		return err
+	}
+	f(s)
+	return s.Save(path)
+}
+
+func serve(ls []lessons.Lesson, args []string, w io.Writer) error {
+	lim := playground.DefaultLimits
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen `address`, which must be a loopback address")
+	maxRuns := fs.Int("max-runs", 4, "programs run at the same time")
+	fs.DurationVar(&lim.RunTimeout, "timeout", lim.RunTimeout, "time limit of a program run")
+	memory := fs.Int64("memory", lim.MemoryBytes>>20, "memory limit of building and running a program in `MiB`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	lim.MemoryBytes = *memory << 20
+
+	ln, err := playground.Listen(*addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Serving the playground on http://%s\n", ln.Addr())
+	return http.Serve(ln, playground.NewServer(ls, lim, *maxRuns))
+}
+
+// warnPrerequisites tells the learner about the prerequisites of l they
+// haven't completed yet.
+func warnPrerequisites(ls []lessons.Lesson, l lessons.Lesson, w io.Writer, progressPath string) error {
+	if progressPath == "" {
+		return nil
+	}
+	s, err := progress.Load(progressPath)
+	if err != nil {
+		return err
+	}
+	missing := lessons.Missing(ls, l, func(id string) bool {
+		lp, ok := s.Lessons[id]
+		return ok && lp.Completed
+	})
+	if len(missing) > 0 {
+		fmt.Fprintf(w, "Note: %s builds on lessons you haven't completed yet: %s\n\n", l.Title(), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func writeBook(ls []lessons.Lesson, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("book", flag.ContinueOnError)
+	format := fs.String("format", "html", "book `format`, md or html")
+	out := fs.String("out", "book", "output `directory`")
+	capture := fs.Bool("output", true, "run the lessons to include their output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	write := book.WriteHTML
+	switch *format {
+	case "html":
+	case "md", "markdown":
+		write = book.WriteMarkdown
+	default:
+		return fmt.Errorf("unknown book format %q", *format)
+	}
+
+	var chs []*book.Chapter
+	for _, l := range ls {
+		ch, err := book.Parse(l)
+		if err != nil {
+			return fmt.Errorf("%s: %w", l.Title(), err)
+		}
+		if *capture {
+			fmt.Fprintf(w, "running %s\n", l.Title())
+			if err := book.Capture(context.Background(), ch, playground.DefaultLimits); err != nil {
+				return err
+			}
+		}
+		chs = append(chs, ch)
+	}
+	if err := write(*out, chs); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "wrote %d chapters to %s\n", len(chs), *out)
+	return nil
+}
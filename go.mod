@@ -0,0 +1,3 @@
+module github.com/ssukruth/little-engine-go
+
+go 1.22
@@ -0,0 +1,231 @@
+// Package book turns the lessons into a book. The prose of a lesson is the
+// comments in its source, each followed by the code it explains and the
+// output that code printed.
+package book
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+// Section is a comment of a lesson together with the code that follows it.
+type Section struct {
+	Prose  string
+	Code   string
+	Output string // printed by Code, see Capture
+
+	// offset of the first statement of Code within the source of the file,
+	// -1 for declarations outside of the lesson's body
+	offset int
+}
+
+// Chapter is the book chapter of a lesson.
+type Chapter struct {
+	Lesson   lessons.Lesson
+	Title    string
+	Summary  string
+	Sections []Section
+
+	src string // source of the file holding Run
+}
+
+// Parse splits the source of the lesson into sections. Declarations before
+// Run become a section each, with their doc comment as prose. Run's body is
+// split at every comment between two of its statements. A Run that only
+// calls another function of the file, like one passing default options to
+// RunWith, is a declaration too, and the body of that function is split
+// instead.
+func Parse(l lessons.Lesson) (*Chapter, error) {
+	ch := &Chapter{Lesson: l, Title: l.Title()}
+	if l.Manifest != nil {
+		ch.Title = l.ID + " " + l.Manifest.Title
+		ch.Summary = l.Manifest.Summary
+	}
+	srcs, err := l.Sources()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range srcs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, path, data, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		run := findRun(f)
+		if run == nil {
+			continue
+		}
+		if fn := delegate(f, run); fn != nil {
+			run = fn
+		}
+		ch.src = string(data)
+		ch.Sections = parseFile(fset, f, run, ch.src)
+		return ch, nil
+	}
+	return nil, errors.New("lesson has no Run function")
+}
+
+func findRun(f *ast.File) *ast.FuncDecl {
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Name.Name == "Run" && fn.Recv == nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// delegate returns the function of f that the single statement of run
+// calls, or nil if run does more than that.
+func delegate(f *ast.File, run *ast.FuncDecl) *ast.FuncDecl {
+	if len(run.Body.List) != 1 {
+		return nil
+	}
+	funcs := map[string]*ast.FuncDecl{}
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && fn != run {
+			funcs[fn.Name.Name] = fn
+		}
+	}
+	var callee *ast.FuncDecl
+	ast.Inspect(run.Body.List[0], func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && callee == nil {
+			if id, ok := call.Fun.(*ast.Ident); ok {
+				callee = funcs[id.Name]
+			}
+		}
+		return callee == nil
+	})
+	return callee
+}
+
+func parseFile(fset *token.FileSet, f *ast.File, run *ast.FuncDecl, src string) []Section {
+	off := func(p token.Pos) int { return fset.Position(p).Offset }
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	var secs []Section
+	// the package doc and the declarations before Run
+	if f.Doc != nil {
+		secs = append(secs, Section{Prose: f.Doc.Text(), offset: -1})
+	}
+	for _, d := range f.Decls {
+		if d == run {
+			continue
+		}
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+			continue
+		}
+		var doc *ast.CommentGroup
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			doc = d.Doc
+		case *ast.GenDecl:
+			doc = d.Doc
+		}
+		code := lines(src, off(d.Pos()), off(d.End()))
+		// undocumented declarations join the previous declarations
+		if n := len(secs); doc == nil && n > 0 && secs[n-1].Code != "" {
+			secs[n-1].Code += "\n\n" + code
+			continue
+		}
+		sec := Section{Code: code, offset: -1}
+		if doc != nil {
+			sec.Prose = doc.Text()
+		}
+		secs = append(secs, sec)
+	}
+
+	// comments between the statements of Run
+	var comments []*ast.CommentGroup
+	for _, c := range f.Comments {
+		if c.Pos() > run.Body.Lbrace && c.End() < run.Body.Rbrace {
+			comments = append(comments, c)
+		}
+	}
+	body := run.Body.List
+	cur := -1 // index in secs of the section being filled
+	prevLine := line(run.Body.Lbrace)
+	var first, last ast.Stmt
+	flush := func() {
+		if first != nil {
+			secs[cur].Code = dedent(lines(src, off(first.Pos()), off(last.End())))
+			secs[cur].offset = off(first.Pos())
+		}
+		first, last = nil, nil
+	}
+	for i := 0; i <= len(body); i++ {
+		end := run.Body.Rbrace
+		if i < len(body) {
+			end = body[i].Pos()
+		}
+		var prose []string
+		for len(comments) > 0 && comments[0].End() <= end {
+			c := comments[0]
+			comments = comments[1:]
+			// trailing comments stay with the code on their line
+			if line(c.Pos()) == prevLine && first != nil {
+				continue
+			}
+			// comments within a statement stay with the statement
+			if last != nil && c.Pos() < last.End() {
+				continue
+			}
+			prose = append(prose, c.Text())
+		}
+		if len(prose) > 0 || cur < 0 {
+			flush()
+			secs = append(secs, Section{Prose: strings.Join(prose, "\n"), offset: -1})
+			cur = len(secs) - 1
+		}
+		if i == len(body) {
+			break
+		}
+		if first == nil {
+			first = body[i]
+		}
+		last = body[i]
+		prevLine = line(body[i].End())
+	}
+	flush()
+
+	// drop sections without any content, e.g. an empty Run
+	var res []Section
+	for _, s := range secs {
+		if strings.TrimSpace(s.Prose) != "" || strings.TrimSpace(s.Code) != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// lines returns the source from the start of the line holding offset start
+// to the end of the line holding offset end, including trailing comments.
+func lines(src string, start, end int) string {
+	for start > 0 && src[start-1] != '\n' {
+		start--
+	}
+	if i := strings.IndexByte(src[end:], '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(src)
+	}
+	return src[start:end]
+}
+
+// dedent removes the indentation of the body of Run.
+func dedent(code string) string {
+	ls := strings.Split(code, "\n")
+	for i, l := range ls {
+		ls[i] = strings.TrimPrefix(l, "\t")
+	}
+	return strings.Join(ls, "\n")
+}
@@ -0,0 +1,152 @@
+package book
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/playground"
+)
+
+const lessonSrc = `// Package demo is a lesson.
+package demo
+
+import "fmt"
+
+type day int
+
+func twice(n int) int {
+	return 2 * n
+}
+
+func Run() {
+	// Printing a number
+	fmt.Println(twice(2)) // => 4
+
+	// Slices of two lines
+	/*
+		x := []int{}
+		x[0] = 1 // panics
+	*/
+	s := []int{1}
+	fmt.Println(s)
+	if len(s) > 0 {
+		// inside the if statement
+		fmt.Println("not empty")
+	}
+}
+`
+
+func TestParse(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "demo.go"), []byte(lessonSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := Parse(lessons.Lesson{ID: "0099", Name: "demo", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ prose, code string }{
+		{"Package demo is a lesson.\n", ""},
+		{"", "type day int\n\nfunc twice(n int) int {\n\treturn 2 * n\n}"},
+		{"Printing a number\n", "fmt.Println(twice(2)) // => 4"},
+		{"Slices of two lines\n\n\t\tx := []int{}\n\t\tx[0] = 1 // panics\n", "s := []int{1}\nfmt.Println(s)\nif len(s) > 0 {\n\t// inside the if statement\n\tfmt.Println(\"not empty\")\n}"},
+	}
+	if len(ch.Sections) != len(want) {
+		t.Fatalf("got %d sections, want %d: %+v", len(ch.Sections), len(want), ch.Sections)
+	}
+	for i, w := range want {
+		s := ch.Sections[i]
+		if s.Prose != w.prose || s.Code != w.code {
+			t.Errorf("section %d = %q, %q, want %q, %q", i, s.Prose, s.Code, w.prose, w.code)
+		}
+	}
+
+	blocks := proseBlocks(ch.Sections[3].Prose)
+	if len(blocks) != 2 || blocks[0].Code || !blocks[1].Code || blocks[1].Text != "x := []int{}\nx[0] = 1 // panics" {
+		t.Errorf("prose blocks = %+v", blocks)
+	}
+
+	out := filepath.Join(t.TempDir(), "book")
+	if err := WriteMarkdown(out, []*Chapter{ch}); err != nil {
+		t.Fatal(err)
+	}
+	md, err := os.ReadFile(filepath.Join(out, "0099_demo.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "Printing a number\n\n```go\nfmt.Println(twice(2)) // => 4\n```") {
+		t.Errorf("markdown chapter doesn't interleave prose and code:\n%s", md)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the lesson with the go toolchain")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "demo.go"), []byte(lessonSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := Parse(lessons.Lesson{ID: "0099", Name: "demo", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Capture(context.Background(), ch, playground.DefaultLimits); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range ch.Sections {
+		got = append(got, s.Output)
+	}
+	if want := []string{"", "", "4\n", "[1]\nnot empty\n"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("section outputs = %q, want %q", got, want)
+	}
+}
+
+func TestParseFollowsRunWith(t *testing.T) {
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := lessons.Find(ls, "0011")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := Parse(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run only calls RunWith, whose body is split like Run's of the other
+	// lessons
+	var create *Section
+	for i, s := range ch.Sections {
+		if strings.Contains(s.Code, "func Run() {") && s.offset >= 0 {
+			t.Errorf("Run is in the body of the lesson: %q", s.Code)
+		}
+		if strings.Contains(s.Prose, "Creating new File") {
+			create = &ch.Sections[i]
+		}
+	}
+	if create == nil || !strings.HasPrefix(create.Code, "var newFile *os.File\n") {
+		t.Fatalf("no section of RunWith's body explains creating a file: %+v", ch.Sections)
+	}
+
+	if testing.Short() {
+		return
+	}
+	if err := Capture(context.Background(), ch, playground.DefaultLimits); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(create.Output, "newFile is of type *os.File\n") {
+		t.Errorf("output of creating a file = %q", create.Output)
+	}
+}
@@ -0,0 +1,87 @@
+package book
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/playground"
+)
+
+// marker separates the output of two sections. println writes to stderr,
+// which the playground merges into the same pipe as stdout.
+const marker = "\x1e-book-section-"
+
+// Stdin is fed to lessons that read from standard input.
+const Stdin = "gopher\n"
+
+// Capture runs an instrumented copy of the lesson that prints a marker
+// before the code of every section, and attributes the output between two
+// markers to the section before it. Output of goroutines that are still
+// running when the next section starts may end up in the later section.
+func Capture(ctx context.Context, ch *Chapter, lim playground.Limits) error {
+	type insert struct {
+		offset  int
+		section int
+	}
+	var inserts []insert
+	for i, s := range ch.Sections {
+		if s.offset >= 0 {
+			inserts = append(inserts, insert{s.offset, i})
+		}
+	}
+	sort.Slice(inserts, func(i, j int) bool { return inserts[i].offset < inserts[j].offset })
+
+	var b strings.Builder
+	last := 0
+	for _, in := range inserts {
+		b.WriteString(ch.src[last:in.offset])
+		fmt.Fprintf(&b, "println(%q); ", marker+strconv.Itoa(in.section))
+		last = in.offset
+	}
+	b.WriteString(ch.src[last:])
+
+	res, err := playground.Run(ctx, playground.Program{
+		Source:     playground.Standalone(b.String()),
+		Stdin:      Stdin,
+		FixtureDir: ch.Lesson.Dir,
+		ModuleDir:  moduleDir(ch.Lesson.Dir),
+	}, lim)
+	if err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("%s: instrumented lesson doesn't compile: line %d: %s", ch.Lesson.Title(), res.Errors[0].Line, res.Errors[0].Message)
+	}
+
+	cur := -1
+	for _, line := range strings.SplitAfter(res.Output, "\n") {
+		if strings.HasPrefix(line, marker) {
+			cur, err = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, marker)))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if cur >= 0 {
+			ch.Sections[cur].Output += line
+		}
+	}
+	if res.TimedOut {
+		return fmt.Errorf("%s: timed out", ch.Lesson.Title())
+	}
+	return nil
+}
+
+// moduleDir returns the root of the module the lesson in dir is part of, or
+// "" for a lesson outside of the repository.
+func moduleDir(dir string) string {
+	root, err := lessons.FindRoot(dir)
+	if err != nil {
+		return ""
+	}
+	return root
+}
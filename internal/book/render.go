@@ -0,0 +1,189 @@
+package book
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ssukruth/little-engine-go/internal/playground"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"highlight": playground.Highlight,
+	"prose":     proseBlocks,
+	"page":      page,
+}).ParseFS(templateFS, "templates/*.html"))
+
+// block is a paragraph of prose or a snippet of code quoted in a comment,
+// such as the commented out examples of invalid code.
+type block struct {
+	Code bool
+	Text string
+}
+
+// proseBlocks splits comment text into paragraphs and indented code.
+func proseBlocks(text string) []block {
+	var blocks []block
+	add := func(code bool, line string) {
+		if n := len(blocks); n > 0 && blocks[n-1].Code == code {
+			blocks[n-1].Text += "\n" + line
+			return
+		}
+		blocks = append(blocks, block{Code: code, Text: line})
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			if n := len(blocks); n > 0 && blocks[n-1].Code {
+				blocks[n-1].Text += "\n"
+			} else if n > 0 {
+				blocks = append(blocks, block{})
+			}
+		case strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    "):
+			add(true, line)
+		default:
+			add(false, strings.TrimSpace(line))
+		}
+	}
+	var res []block
+	for _, b := range blocks {
+		if strings.TrimSpace(b.Text) == "" {
+			continue
+		}
+		if b.Code {
+			b.Text = trimIndent(strings.TrimRight(b.Text, "\n"))
+		}
+		res = append(res, b)
+	}
+	return res
+}
+
+// trimIndent removes the indentation common to all non-blank lines.
+func trimIndent(code string) string {
+	lines := strings.Split(code, "\n")
+	var indent string
+	first := true
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		lead := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		if first {
+			indent, first = lead, false
+			continue
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// page returns the file name of the chapter of a lesson without extension.
+func page(ch *Chapter) string {
+	return ch.Lesson.Title()
+}
+
+// WriteMarkdown writes the chapters as a markdown book to dir, one file per
+// chapter and a README.md with the table of contents.
+func WriteMarkdown(dir string, chs []*Chapter) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var toc strings.Builder
+	toc.WriteString("# little-engine-go\n\n")
+	for _, ch := range chs {
+		fmt.Fprintf(&toc, "- [%s](%s.md)", ch.Title, page(ch))
+		if ch.Summary != "" {
+			fmt.Fprintf(&toc, ": %s", ch.Summary)
+		}
+		toc.WriteString("\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(toc.String()), 0644); err != nil {
+		return err
+	}
+
+	for i, ch := range chs {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s\n\n", ch.Title)
+		if ch.Summary != "" {
+			fmt.Fprintf(&b, "*%s*\n\n", ch.Summary)
+		}
+		for _, s := range ch.Sections {
+			for _, p := range proseBlocks(s.Prose) {
+				if p.Code {
+					fmt.Fprintf(&b, "```go\n%s\n```\n\n", p.Text)
+				} else {
+					fmt.Fprintf(&b, "%s\n\n", p.Text)
+				}
+			}
+			if strings.TrimSpace(s.Code) != "" {
+				fmt.Fprintf(&b, "```go\n%s\n```\n\n", strings.Trim(s.Code, "\n"))
+			}
+			if s.Output != "" {
+				fmt.Fprintf(&b, "Output:\n\n```text\n%s\n```\n\n", strings.TrimRight(s.Output, "\n"))
+			}
+		}
+		b.WriteString("---\n\n")
+		if i > 0 {
+			fmt.Fprintf(&b, "Previous: [%s](%s.md) | ", chs[i-1].Title, page(chs[i-1]))
+		}
+		b.WriteString("[Contents](README.md)")
+		if i < len(chs)-1 {
+			fmt.Fprintf(&b, " | Next: [%s](%s.md)", chs[i+1].Title, page(chs[i+1]))
+		}
+		b.WriteString("\n")
+		if err := os.WriteFile(filepath.Join(dir, page(ch)+".md"), []byte(b.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHTML writes the chapters as a static site to dir, one page per
+// chapter and an index.html with the table of contents.
+func WriteHTML(dir string, chs []*Chapter) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeTemplate(filepath.Join(dir, "index.html"), "index.html", chs); err != nil {
+		return err
+	}
+	for i, ch := range chs {
+		data := struct {
+			Chapter    *Chapter
+			Prev, Next *Chapter
+		}{Chapter: ch}
+		if i > 0 {
+			data.Prev = chs[i-1]
+		}
+		if i < len(chs)-1 {
+			data.Next = chs[i+1]
+		}
+		if err := writeTemplate(filepath.Join(dir, page(ch)+".html"), "chapter.html", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTemplate(path, name string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := templates.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,230 @@
+// Package checker runs the hidden checker tests of a lesson's exercises and
+// collects the hints of the checks that failed.
+//
+// Checker tests live next to the exercises in files guarded by the "checker"
+// build tag, so that the learner stubs don't fail a plain "go test ./...".
+// Reference solutions are guarded by the "solution" build tag, and
+//
+//	go test -tags "checker solution" ./...
+//
+// verifies that every checker passes against its solution.
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hintPrefix marks the hint lines in the test output.
+const hintPrefix = "hint: "
+
+// ErrNoExercises is returned by Run for lessons without an exercises package.
+var ErrNoExercises = errors.New("lesson has no exercises")
+
+// Hint registers a hint that is shown to the learner if t fails.
+func Hint(t testing.TB, hint string) {
+	t.Helper()
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(hintPrefix + hint)
+		}
+	})
+}
+
+// Check is the outcome of a single checker test.
+type Check struct {
+	Name    string
+	Passed  bool
+	Output  []string // failure messages without the hints
+	Hints   []string
+	Elapsed time.Duration
+
+	inRace bool // race detector output seen, only keep test logs
+}
+
+// Report is the outcome of checking the exercises of a lesson.
+type Report struct {
+	Dir    string
+	Checks []Check
+	// BuildOutput holds the compiler errors if the exercises didn't build.
+	BuildOutput string
+}
+
+// Passed reports whether the exercises built and every check passed.
+func (r Report) Passed() bool {
+	if r.BuildOutput != "" || len(r.Checks) == 0 {
+		return false
+	}
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the names of the checks that failed.
+func (r Report) Failed() []string {
+	var names []string
+	for _, c := range r.Checks {
+		if !c.Passed {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Run runs the checker tests of the exercises under lessonDir with the go
+// toolchain. Extra build tags, e.g. "solution", are passed to go test.
+func Run(lessonDir string, tags ...string) (Report, error) {
+	dir := filepath.Join(lessonDir, "exercises")
+	rep := Report{Dir: dir}
+	if _, err := os.Stat(dir); err != nil {
+		return rep, ErrNoExercises
+	}
+
+	args := []string{"test", "-json", "-count=1", "-tags", strings.Join(append([]string{"checker"}, tags...), ",")}
+	if race() {
+		args = append(args, "-race")
+	}
+	cmd := exec.Command("go", append(args, ".")...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return rep, err
+	}
+
+	rep.Checks, rep.BuildOutput = parse(bytes.NewReader(stdout))
+	if stderr.Len() > 0 && len(rep.Checks) == 0 {
+		rep.BuildOutput += stderr.String()
+	}
+	return rep, nil
+}
+
+// race reports whether the race detector can be used, which requires cgo.
+func race() bool {
+	out, err := exec.Command("go", "env", "CGO_ENABLED").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "1"
+}
+
+// event is a line of "go test -json" output.
+type event struct {
+	Action  string
+	Test    string
+	Output  string
+	Elapsed float64
+}
+
+func parse(r io.Reader) ([]Check, string) {
+	checks := map[string]*Check{}
+	var build strings.Builder
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var e event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			build.WriteString(sc.Text() + "\n")
+			continue
+		}
+		if e.Action == "build-output" {
+			build.WriteString(e.Output)
+			continue
+		}
+		// only report top level tests
+		if e.Test == "" || strings.Contains(e.Test, "/") {
+			continue
+		}
+		c, ok := checks[e.Test]
+		if !ok {
+			c = &Check{Name: e.Test}
+			checks[e.Test] = c
+		}
+		switch e.Action {
+		case "output":
+			addOutput(c, e.Output)
+		case "pass":
+			c.Passed = true
+			c.Elapsed = time.Duration(e.Elapsed * float64(time.Second))
+		case "fail":
+			c.Elapsed = time.Duration(e.Elapsed * float64(time.Second))
+		}
+	}
+
+	var res []Check
+	for _, c := range checks {
+		res = append(res, *c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, build.String()
+}
+
+// location matches the "file_test.go:12: " prefix added by t.Log.
+var location = regexp.MustCompile(`^\w+\.go:\d+: `)
+
+func addOutput(c *Check, line string) {
+	line = strings.TrimRight(line, "\n")
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") || trimmed == "" {
+		return
+	}
+	// race detector reports are long stack traces, keep just the verdict
+	const separator = "=================="
+	switch {
+	case trimmed == "WARNING: DATA RACE":
+		if !c.inRace {
+			c.Output = append(c.Output, "data race detected")
+		}
+		c.inRace = true
+		return
+	case trimmed == separator, c.inRace && !location.MatchString(trimmed):
+		return
+	case strings.HasSuffix(trimmed, "race detected during execution of test"):
+		return
+	}
+	trimmed = location.ReplaceAllString(trimmed, "")
+	if strings.HasPrefix(trimmed, hintPrefix) {
+		c.Hints = append(c.Hints, strings.TrimPrefix(trimmed, hintPrefix))
+		return
+	}
+	c.Output = append(c.Output, trimmed)
+}
+
+// Print writes a human readable summary of rep to w.
+func Print(w io.Writer, rep Report) {
+	if rep.BuildOutput != "" {
+		fmt.Fprintln(w, "The exercises don't compile yet:")
+		fmt.Fprint(w, rep.BuildOutput)
+		return
+	}
+	for _, c := range rep.Checks {
+		if c.Passed {
+			fmt.Fprintf(w, "PASS  %s\n", c.Name)
+			continue
+		}
+		fmt.Fprintf(w, "FAIL  %s\n", c.Name)
+		for _, o := range c.Output {
+			fmt.Fprintf(w, "      %s\n", o)
+		}
+		for _, h := range c.Hints {
+			fmt.Fprintf(w, "      hint: %s\n", h)
+		}
+	}
+	if rep.Passed() {
+		fmt.Fprintf(w, "\nAll %d checks passed.\n", len(rep.Checks))
+	} else {
+		fmt.Fprintf(w, "\n%d of %d checks failed.\n", len(rep.Failed()), len(rep.Checks))
+	}
+}
@@ -0,0 +1,63 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/checker"
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+func TestSolutionsPass(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test for every lesson")
+	}
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range ls {
+		t.Run(l.Title(), func(t *testing.T) {
+			t.Parallel()
+			rep, err := checker.Run(l.Dir, "solution")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rep.Passed() {
+				t.Errorf("solution fails checks %v\n%s", rep.Failed(), rep.BuildOutput)
+			}
+		})
+	}
+}
+
+func TestStubsFail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test for a lesson")
+	}
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := lessons.Find(ls, "0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := checker.Run(l.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Passed() {
+		t.Fatal("the exercise stubs pass the checks")
+	}
+	c := rep.Checks[0]
+	if c.Name != "TestGreet" || c.Passed || len(c.Output) == 0 || len(c.Hints) != 1 {
+		t.Errorf("unexpected report for the stubs: %+v", c)
+	}
+}
@@ -0,0 +1,166 @@
+// Package lessons discovers the numbered lesson directories of the
+// repository and maps each of them to its in-process entry point.
+package lessons
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	helloworld "github.com/ssukruth/little-engine-go/0001_hello_world"
+	variables "github.com/ssukruth/little-engine-go/0002_variables_and_consts"
+	explorefmt "github.com/ssukruth/little-engine-go/0003_fmt"
+	datatypes "github.com/ssukruth/little-engine-go/0004_data_types"
+	operators "github.com/ssukruth/little-engine-go/0005_operators"
+	flowcontrol "github.com/ssukruth/little-engine-go/0006_flow_control"
+	arrays "github.com/ssukruth/little-engine-go/0007_arrays"
+	slices "github.com/ssukruth/little-engine-go/0008_slices"
+	strs "github.com/ssukruth/little-engine-go/0009_strings"
+	maps "github.com/ssukruth/little-engine-go/0010_maps"
+	files "github.com/ssukruth/little-engine-go/0011_files"
+	structs "github.com/ssukruth/little-engine-go/0012_structs"
+	functions "github.com/ssukruth/little-engine-go/0013_functions"
+	pointers "github.com/ssukruth/little-engine-go/0014_pointers"
+	methods "github.com/ssukruth/little-engine-go/0015_methods"
+	interfaces "github.com/ssukruth/little-engine-go/0016_interfaces"
+	concurrency "github.com/ssukruth/little-engine-go/0017_concurrency"
+	primitives "github.com/ssukruth/little-engine-go/0018_sync_primitives"
+	rwcond "github.com/ssukruth/little-engine-go/0019_rwmutex_cond"
+	classic "github.com/ssukruth/little-engine-go/0020_classic_problems"
+)
+
+// registry maps a lesson id to the function holding the lesson's main logic.
+// Every new numbered directory must be added here to be runnable in-process.
+var registry = map[string]func(){
+	"0001": helloworld.Run,
+	"0002": variables.Run,
+	"0003": explorefmt.Run,
+	"0004": datatypes.Run,
+	"0005": operators.Run,
+	"0006": flowcontrol.Run,
+	"0007": arrays.Run,
+	"0008": slices.Run,
+	"0009": strs.Run,
+	"0010": maps.Run,
+	"0011": files.Run,
+	"0012": structs.Run,
+	"0013": functions.Run,
+	"0014": pointers.Run,
+	"0015": methods.Run,
+	"0016": interfaces.Run,
+	"0017": concurrency.Run,
+	"0018": primitives.Run,
+	"0019": rwcond.Run,
+	"0020": classic.Run,
+}
+
+var dirPattern = regexp.MustCompile(`^(\d{4})_(\w+)$`)
+
+// ErrNotFound is returned by Find when no lesson has the requested id.
+var ErrNotFound = errors.New("lesson not found")
+
+// Lesson is a numbered lesson directory such as 0013_functions.
+type Lesson struct {
+	ID   string // numeric prefix, e.g. "0013"
+	Name string // directory name without the prefix, e.g. "functions"
+	Dir  string // absolute path of the lesson directory
+	Run  func() // nil if the lesson isn't registered
+	// Manifest is nil if the lesson has no lesson.json, see Validate.
+	Manifest *Manifest
+}
+
+// Title returns the directory name of the lesson, e.g. "0013_functions".
+func (l Lesson) Title() string {
+	return l.ID + "_" + l.Name
+}
+
+// Sources returns the paths of the lesson's Go source files, excluding
+// tests and the command wrappers under cmd/.
+func (l Lesson) Sources() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(l.Dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var srcs []string
+	for _, p := range paths {
+		if !strings.HasSuffix(p, "_test.go") {
+			srcs = append(srcs, p)
+		}
+	}
+	return srcs, nil
+}
+
+// Discover lists the numbered lesson directories under root in order.
+// Directories without Go sources, like 0000_readme, are skipped.
+func Discover(root string) ([]Lesson, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var ls []Lesson
+	for _, e := range entries {
+		m := dirPattern.FindStringSubmatch(e.Name())
+		if !e.IsDir() || m == nil {
+			continue
+		}
+		l := Lesson{
+			ID:   m[1],
+			Name: m[2],
+			Dir:  filepath.Join(root, e.Name()),
+			Run:  registry[m[1]],
+		}
+		srcs, err := l.Sources()
+		if err != nil {
+			return nil, err
+		}
+		if len(srcs) == 0 {
+			continue
+		}
+		l.Manifest, err = LoadManifest(l.Dir)
+		if err != nil && err != ErrNoManifest {
+			return nil, fmt.Errorf("%s: %w", l.Title(), err)
+		}
+		ls = append(ls, l)
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].ID < ls[j].ID })
+	return ls, nil
+}
+
+// Find returns the lesson with the given id. The id may be given with or
+// without leading zeros, e.g. "13" and "0013" both refer to 0013_functions.
+func Find(ls []Lesson, id string) (Lesson, error) {
+	id = strings.TrimSpace(id)
+	if len(id) < 4 {
+		id = strings.Repeat("0", 4-len(id)) + id
+	}
+	for _, l := range ls {
+		if l.ID == id || l.Title() == id {
+			return l, nil
+		}
+	}
+	return Lesson{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// FindRoot walks up from dir until it finds the directory holding this
+// repository's go.mod.
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil && strings.Contains(string(data), "module github.com/ssukruth/little-engine-go") {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not inside the little-engine-go repository")
+		}
+		dir = parent
+	}
+}
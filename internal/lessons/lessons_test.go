@@ -0,0 +1,89 @@
+package lessons
+
+import (
+	"strings"
+	"testing"
+)
+
+func discover(t *testing.T) []Lesson {
+	t.Helper()
+	root, err := FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls
+}
+
+// TestManifests fails for lessons added without a complete manifest.
+func TestManifests(t *testing.T) {
+	ls := discover(t)
+	if err := Validate(ls); err != nil {
+		t.Error(err)
+	}
+	for _, l := range ls {
+		if l.Run == nil {
+			t.Errorf("%s isn't registered in lessons.go", l.Title())
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ls := []Lesson{
+		{ID: "0001", Name: "a", Manifest: &Manifest{
+			Title: "A", Summary: "a", Objectives: []string{"a"}, Tags: []string{"concept:a"}, EstimatedMinutes: 1,
+		}},
+		{ID: "0002", Name: "b", Manifest: &Manifest{
+			Title: "B", Objectives: []string{"b"}, Tags: []string{"topic:b"}, Prerequisites: []string{"0003", "0009"},
+		}},
+		{ID: "0003", Name: "c"},
+	}
+	err := Validate(ls)
+	if err == nil {
+		t.Fatal("Validate accepted invalid manifests")
+	}
+	for _, want := range []string{
+		"0002_b: missing summary",
+		`0002_b: invalid tag "topic:b"`,
+		"0002_b: missing estimated_minutes",
+		"0002_b: prerequisite 0003 doesn't come before the lesson",
+		"0002_b: prerequisite 0009 doesn't exist",
+		"0003_c: missing lesson.json",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error doesn't report %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "0001_a") {
+		t.Errorf("Validate reported the valid manifest:\n%v", err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	ls := discover(t)
+	l, err := Find(ls, "0014")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := map[string]bool{"0001": true, "0002": true, "0012": true}
+	got := Missing(ls, l, func(id string) bool { return done[id] })
+	if want := "0003 0004 0005 0006 0007 0008 0013"; strings.Join(got, " ") != want {
+		t.Errorf("Missing(0014) = %v, want %v", got, want)
+	}
+}
+
+func TestFilterTag(t *testing.T) {
+	ls := discover(t)
+	var got []string
+	for _, l := range ls {
+		if l.Manifest.HasTag("concept:closures") {
+			got = append(got, l.ID)
+		}
+	}
+	if strings.Join(got, " ") != "0013" {
+		t.Errorf("lessons tagged concept:closures = %v, want [0013]", got)
+	}
+}
@@ -0,0 +1,172 @@
+package lessons
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the name of the manifest in every lesson directory.
+const ManifestFile = "lesson.json"
+
+// Manifest is the machine readable description of a lesson.
+type Manifest struct {
+	Title         string   `json:"title"`
+	Summary       string   `json:"summary"`
+	Objectives    []string `json:"objectives"`
+	Prerequisites []string `json:"prerequisites"` // lesson ids
+	// Tags are "kind:value" pairs such as "stdlib:bufio" or "concept:closures".
+	Tags             []string `json:"tags"`
+	EstimatedMinutes int      `json:"estimated_minutes"`
+}
+
+// Estimated returns the estimated time to complete the lesson.
+func (m *Manifest) Estimated() time.Duration {
+	return time.Duration(m.EstimatedMinutes) * time.Minute
+}
+
+// HasTag reports whether the manifest has the tag. A tag without a value,
+// e.g. "stdlib", matches every tag of that kind.
+func (m *Manifest) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag || (!strings.Contains(tag, ":") && strings.HasPrefix(t, tag+":")) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagKinds are the valid kinds of manifest tags.
+var TagKinds = []string{"concept", "stdlib"}
+
+var (
+	tagPattern = regexp.MustCompile(`^([a-z]+):([a-z0-9/._-]+)$`)
+	idPattern  = regexp.MustCompile(`^\d{4}$`)
+)
+
+// ErrNoManifest is returned by LoadManifest for lessons without a manifest.
+var ErrNoManifest = errors.New("lesson has no " + ManifestFile)
+
+// LoadManifest reads the manifest of the lesson in dir.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return &m, nil
+}
+
+// Validate checks that every lesson has a complete manifest whose
+// prerequisites are earlier lessons. It returns all problems found.
+func Validate(ls []Lesson) error {
+	ids := map[string]bool{}
+	for _, l := range ls {
+		ids[l.ID] = true
+	}
+
+	var errs []error
+	for _, l := range ls {
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("%s: %s", l.Title(), fmt.Sprintf(format, args...)))
+		}
+		m := l.Manifest
+		if m == nil {
+			fail("missing %s", ManifestFile)
+			continue
+		}
+		if strings.TrimSpace(m.Title) == "" {
+			fail("missing title")
+		}
+		if strings.TrimSpace(m.Summary) == "" {
+			fail("missing summary")
+		}
+		if len(m.Objectives) == 0 {
+			fail("missing objectives")
+		}
+		for _, o := range m.Objectives {
+			if strings.TrimSpace(o) == "" {
+				fail("empty objective")
+			}
+		}
+		if len(m.Tags) == 0 {
+			fail("missing tags")
+		}
+		for _, t := range m.Tags {
+			match := tagPattern.FindStringSubmatch(t)
+			if match == nil || !validKind(match[1]) {
+				fail("invalid tag %q, tags are kind:value with kind one of %s", t, strings.Join(TagKinds, ", "))
+			}
+		}
+		if m.EstimatedMinutes <= 0 {
+			fail("missing estimated_minutes")
+		}
+		for _, p := range m.Prerequisites {
+			switch {
+			case !idPattern.MatchString(p):
+				fail("invalid prerequisite %q, use the 4 digit lesson id", p)
+			case !ids[p]:
+				fail("prerequisite %s doesn't exist", p)
+			case p >= l.ID:
+				fail("prerequisite %s doesn't come before the lesson", p)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validKind(kind string) bool {
+	for _, k := range TagKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the prerequisites of l, direct or indirect, for which
+// done returns false, in lesson order.
+func Missing(ls []Lesson, l Lesson, done func(id string) bool) []string {
+	byID := map[string]Lesson{}
+	for _, l := range ls {
+		byID[l.ID] = l
+	}
+	seen := map[string]bool{}
+	var visit func(l Lesson)
+	visit = func(l Lesson) {
+		if l.Manifest == nil {
+			return
+		}
+		for _, p := range l.Manifest.Prerequisites {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if pl, ok := byID[p]; ok {
+				visit(pl)
+			}
+		}
+	}
+	visit(l)
+
+	var missing []string
+	for _, l := range ls {
+		if seen[l.ID] && !done(l.ID) {
+			missing = append(missing, l.ID)
+		}
+	}
+	return missing
+}
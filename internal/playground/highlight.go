@@ -0,0 +1,68 @@
+package playground
+
+import (
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Highlight returns src as HTML with every token wrapped in a span whose
+// class names its kind: kw, str, num, com, ident or op.
+func Highlight(src string) template.HTML {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+
+	var b strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		off := file.Offset(pos)
+		// the scanner inserts semicolons that aren't in the source
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		end := off + len(lit)
+		if lit == "" {
+			end = off + len(tok.String())
+		}
+		if off < last || end > len(src) {
+			continue
+		}
+		b.WriteString(html.EscapeString(src[last:off]))
+		class := tokenClass(tok)
+		text := html.EscapeString(src[off:end])
+		if class == "" {
+			b.WriteString(text)
+		} else {
+			b.WriteString(`<span class="` + class + `">` + text + `</span>`)
+		}
+		last = end
+	}
+	b.WriteString(html.EscapeString(src[last:]))
+	return template.HTML(b.String())
+}
+
+func tokenClass(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return "kw"
+	case tok == token.STRING || tok == token.CHAR:
+		return "str"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "num"
+	case tok == token.COMMENT:
+		return "com"
+	case tok == token.IDENT:
+		return "ident"
+	case tok.IsOperator():
+		return "op"
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package playground
+
+import (
+	"context"
+	"os/exec"
+)
+
+// limitedCommand runs name with the timeout of ctx. The memory limit is only
+// enforced through GOMEMLIMIT on this platform.
+func limitedCommand(ctx context.Context, lim Limits, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package playground
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// limitedCommand runs name through the shell to apply the memory limit with
+// ulimit, in its own process group so that a timeout kills any children too.
+// The data segment is limited rather than the address space, since the go
+// runtime reserves far more address space than it uses.
+func limitedCommand(ctx context.Context, lim Limits, name string, args ...string) *exec.Cmd {
+	kb := strconv.FormatInt(lim.MemoryBytes/1024, 10)
+	args = append([]string{"-c", `ulimit -d "$1" && shift && exec "$@"`, "sh", kb, name}, args...)
+	cmd := exec.CommandContext(ctx, "/bin/sh", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
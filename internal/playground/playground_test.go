@@ -0,0 +1,181 @@
+package playground
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+func TestHighlight(t *testing.T) {
+	got := string(Highlight("func f() string { return \"<b>\" } // done\n"))
+	for _, want := range []string{
+		`<span class="kw">func</span>`,
+		`<span class="str">&#34;&lt;b&gt;&#34;</span>`,
+		`<span class="com">// done</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Highlight output %s doesn't contain %s", got, want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds programs with the go toolchain")
+	}
+	ctx := context.Background()
+	lim := DefaultLimits
+	lim.RunTimeout = 2 * time.Second
+
+	res, err := Run(ctx, Program{Source: `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	s := bufio.NewScanner(os.Stdin)
+	s.Scan()
+	fmt.Println("Hello", s.Text())
+}
+`, Stdin: "gopher\n"}, lim)
+	if err != nil || res.Output != "Hello gopher\n" || res.ExitCode != 0 {
+		t.Errorf("hello program = %+v, %v", res, err)
+	}
+
+	res, err = Run(ctx, Program{Source: "package main\n\nfunc main() {\n\tx := 1\n}\n"}, lim)
+	if err != nil || len(res.Errors) != 1 || res.Errors[0].Line != 4 {
+		t.Errorf("program with an unused variable = %+v, %v", res, err)
+	}
+
+	res, err = Run(ctx, Program{Source: "package main\n\nfunc main() {\n\tfor {\n\t}\n}\n"}, lim)
+	if err != nil || !res.TimedOut {
+		t.Errorf("endless loop = %+v, %v", res, err)
+	}
+
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = Run(ctx, Program{Source: `package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+)
+
+func main() {
+	c := clock.NewFake(time.Time{})
+	c.Advance(time.Hour)
+	fmt.Println(c.Now().Format(time.Kitchen))
+}
+`, ModuleDir: root}, lim)
+	if err != nil || res.Output != "1:00AM\n" {
+		t.Errorf("program importing pkg/clock = %+v, %v", res, err)
+	}
+
+	lim.OutputBytes = 10
+	res, err = Run(ctx, Program{Source: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"0123456789abc\")\n}\n"}, lim)
+	if err != nil || res.Output != "0123456789" || !res.Truncated {
+		t.Errorf("program exceeding the output limit = %+v, %v", res, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewServer(ls, DefaultLimits, 1))
+	defer srv.Close()
+
+	for path, want := range map[string]string{
+		"/":               "0016 interfaces",
+		"/lessons/0016":   `<span class="kw">package</span> <span class="ident">main</span>`,
+		"/lessons/9999":   "404 page not found",
+		"/lessons/0001/x": "404 page not found",
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), want) {
+			t.Errorf("GET %s doesn't contain %q", path, want)
+		}
+	}
+}
+
+func TestServerRefusesCrossSiteRuns(t *testing.T) {
+	srv := httptest.NewServer(NewServer(nil, DefaultLimits, 1))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name, contentType, origin string
+		want                      int
+	}{
+		// what a form or fetch of any web page can send without asking
+		{"text", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"form", "application/x-www-form-urlencoded", "http://evil.example", http.StatusUnsupportedMediaType},
+		{"other origin", "application/json", "http://evil.example", http.StatusForbidden},
+		{"other port", "application/json", "http://127.0.0.1:1", http.StatusForbidden},
+		// accepted, and rejected only for the body that isn't a program
+		{"same origin", "application/json; charset=utf-8", srv.URL, http.StatusBadRequest},
+		{"no origin", "application/json", "", http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("POST", srv.URL+"/run", strings.NewReader("not json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", tc.contentType)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: POST /run = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestListenOnlyOnLoopback(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", ":0", "[::]:0", "192.0.2.1:0", "example.com:0"} {
+		if ln, err := Listen(addr); !errors.Is(err, ErrNotLoopback) {
+			if ln != nil {
+				ln.Close()
+			}
+			t.Errorf("Listen(%q) = %v, want %v", addr, err, ErrNotLoopback)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+		ln, err := Listen(addr)
+		if err != nil {
+			t.Errorf("Listen(%q) = %v", addr, err)
+			continue
+		}
+		ln.Close()
+	}
+}
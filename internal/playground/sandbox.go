@@ -0,0 +1,228 @@
+package playground
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits bounds the resources of a single run.
+type Limits struct {
+	BuildTimeout time.Duration // time allowed to compile the program
+	RunTimeout   time.Duration // time allowed to run the program
+	MemoryBytes  int64         // memory limit of the build and of the program
+	OutputBytes  int           // output kept from the program
+}
+
+// DefaultLimits are suitable for the lessons on a shared machine.
+var DefaultLimits = Limits{
+	BuildTimeout: 30 * time.Second,
+	RunTimeout:   20 * time.Second,
+	MemoryBytes:  512 << 20,
+	OutputBytes:  64 << 10,
+}
+
+// CompileError is a compiler error in the submitted program.
+type CompileError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of running a program.
+type Result struct {
+	Errors    []CompileError `json:"errors,omitempty"`
+	Output    string         `json:"output"`
+	ExitCode  int            `json:"exit_code"`
+	TimedOut  bool           `json:"timed_out"`
+	Truncated bool           `json:"truncated"`
+	Duration  time.Duration  `json:"duration"`
+}
+
+// ErrBuildTimeout is returned when the program takes too long to compile.
+var ErrBuildTimeout = errors.New("build timed out")
+
+// errPattern matches compiler errors such as "./main.go:12:5: undefined: x".
+var errPattern = regexp.MustCompile(`(?m)^\.?/?main\.go:(\d+)(?::(\d+))?: (.*)$`)
+
+// Program is a single file main package to run.
+type Program struct {
+	Source string
+	Stdin  string
+	// FixtureDir is copied into the working directory of the program if
+	// set, so that lessons find the files they read. Go sources and the
+	// cmd, exercises, snippets and testdata directories are skipped.
+	FixtureDir string
+	// ModuleDir is the root of the little-engine-go module if set, which
+	// the program may then import packages of, such as pkg/clock.
+	ModuleDir string
+}
+
+// Run compiles the program as the main package of a throwaway module in a
+// temporary directory with the local go toolchain and runs it within lim.
+// The program runs as the current user, see the package documentation.
+func Run(ctx context.Context, p Program, lim Limits) (Result, error) {
+	var res Result
+	dir, err := os.MkdirTemp("", "little-engine-play-")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(dir)
+
+	if p.FixtureDir != "" {
+		if err := copyFixtures(p.FixtureDir, dir); err != nil {
+			return res, err
+		}
+	}
+	gomod, err := goMod(p.ModuleDir)
+	if err != nil {
+		return res, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644); err != nil {
+		return res, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(p.Source), 0644); err != nil {
+		return res, err
+	}
+
+	bctx, cancel := context.WithTimeout(ctx, lim.BuildTimeout)
+	defer cancel()
+	// the build runs code too, like the compiler on hostile input, so it
+	// gets the same memory limit as the program
+	build := limitedCommand(bctx, lim, "go", "build", "-o", "prog", ".")
+	build.Dir = dir
+	// no network access and no toolchain downloads
+	build.Env = append(os.Environ(), "GOPROXY=off", "GOTOOLCHAIN=local", "GOFLAGS=-mod=mod")
+	build.WaitDelay = time.Second
+	out, err := build.CombinedOutput()
+	if bctx.Err() == context.DeadlineExceeded {
+		return res, ErrBuildTimeout
+	}
+	if err != nil {
+		res.Errors = parseErrors(string(out))
+		if len(res.Errors) == 0 {
+			return res, fmt.Errorf("build failed: %s", out)
+		}
+		return res, nil
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, lim.RunTimeout)
+	defer cancel()
+	cmd := limitedCommand(rctx, lim, filepath.Join(dir, "prog"))
+	cmd.Dir = dir
+	cmd.Env = []string{"HOME=" + dir, "TMPDIR=" + dir, "GOMEMLIMIT=" + strconv.FormatInt(lim.MemoryBytes/2, 10)}
+	cmd.Stdin = strings.NewReader(p.Stdin)
+	outBuf := &limitedBuffer{max: lim.OutputBytes}
+	cmd.Stdout = outBuf
+	cmd.Stderr = outBuf
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	res.Duration = time.Since(start)
+	res.Output = outBuf.String()
+	res.Truncated = outBuf.truncated
+	res.TimedOut = rctx.Err() == context.DeadlineExceeded
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil && !res.TimedOut:
+		return res, err
+	}
+	return res, nil
+}
+
+// goMod returns the go.mod of the throwaway module, which requires the module
+// in moduleDir from the local directory if it isn't empty.
+func goMod(moduleDir string) (string, error) {
+	if moduleDir == "" {
+		return "module play\n", nil
+	}
+	dir, err := filepath.Abs(moduleDir)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	m := modulePath.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("%s/go.mod has no module line", dir)
+	}
+	return fmt.Sprintf("module play\n\nrequire %s v0.0.0\n\nreplace %[1]s => %q\n", m[1], dir), nil
+}
+
+var modulePath = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+func copyFixtures(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case rel == ".":
+			return nil
+		case d.IsDir() && (rel == "cmd" || rel == "exercises" || rel == "snippets" || rel == "testdata"):
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		case strings.HasSuffix(rel, ".go") || !d.Type().IsRegular():
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+}
+
+func parseErrors(out string) []CompileError {
+	var errs []CompileError
+	for _, m := range errPattern.FindAllStringSubmatch(out, -1) {
+		line, _ := strconv.Atoi(m[1])
+		col, _ := strconv.Atoi(m[2])
+		errs = append(errs, CompileError{Line: line, Column: col, Message: m[3]})
+	}
+	return errs
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so that a program printing in a loop can't exhaust the server's
+// memory. The buffer isn't embedded so that its ReadFrom method can't bypass
+// the limit.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
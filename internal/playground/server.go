@@ -0,0 +1,208 @@
+// Package playground serves the lessons on a local web page where they can
+// be edited and run with the local go toolchain, replacing play.golang.org
+// for machines without internet access.
+//
+// The programs are not isolated: they run as the user running the server,
+// with access to their files and the network. Limits only stop runaway
+// programs, not malicious ones, so the server only listens on loopback
+// addresses.
+package playground
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// maxSourceBytes bounds the size of a submitted program.
+const maxSourceBytes = 1 << 20
+
+// Server is the playground web server.
+type Server struct {
+	lessons []lessons.Lesson
+	module  string // root of the module the lessons are in
+	limits  Limits
+	runs    chan struct{} // one slot per concurrent run
+	mux     *http.ServeMux
+}
+
+// NewServer returns a playground serving ls that runs at most maxRuns
+// programs at the same time, each within lim.
+func NewServer(ls []lessons.Lesson, lim Limits, maxRuns int) *Server {
+	if maxRuns < 1 {
+		maxRuns = 1
+	}
+	s := &Server{
+		lessons: ls,
+		limits:  lim,
+		runs:    make(chan struct{}, maxRuns),
+		mux:     http.NewServeMux(),
+	}
+	if len(ls) > 0 {
+		// lessons may import the packages under pkg/
+		s.module, _ = lessons.FindRoot(ls[0].Dir)
+	}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /lessons/{id}", s.handleLesson)
+	s.mux.HandleFunc("POST /run", s.handleRun)
+	return s
+}
+
+// ErrNotLoopback is returned by Listen for an address other machines can
+// connect to.
+var ErrNotLoopback = errors.New("playground: programs run unsandboxed, listen on a loopback address only")
+
+// Listen listens for the playground on addr, which must be a loopback
+// address such as 127.0.0.1:8080 or localhost:8080.
+func Listen(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("%w: %s", ErrNotLoopback, addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.render(w, "index.html", s.lessons)
+}
+
+func (s *Server) handleLesson(w http.ResponseWriter, r *http.Request) {
+	l, err := lessons.Find(s.lessons, r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	src, err := Source(l)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "lesson.html", struct {
+		Lesson      lessons.Lesson
+		Source      string
+		Highlighted template.HTML
+	}{l, src, Highlight(src)})
+}
+
+type runRequest struct {
+	Lesson string `json:"lesson"`
+	Source string `json:"source"`
+	Stdin  string `json:"stdin"`
+}
+
+// handleRun compiles and runs the posted program. Browsers let any web page
+// post forms and text to any address without asking, so a page the learner
+// visits could post a program to the playground on their machine. handleRun
+// only accepts JSON, which pages of other origins can't post without the
+// browser asking first, and rejects requests from other origins.
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	var req runRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSourceBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	p := Program{Source: req.Source, Stdin: req.Stdin, ModuleDir: s.module}
+	if l, err := lessons.Find(s.lessons, req.Lesson); err == nil {
+		p.FixtureDir = l.Dir
+	}
+
+	select {
+	case s.runs <- struct{}{}:
+		defer func() { <-s.runs }()
+	case <-r.Context().Done():
+		return
+	}
+	res, err := Run(r.Context(), p, s.limits)
+	switch {
+	case errors.Is(err, ErrBuildTimeout):
+		http.Error(w, err.Error(), http.StatusRequestTimeout)
+		return
+	case err != nil:
+		log.Printf("playground: run failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// sameOrigin reports whether r comes from a page of the playground, or not
+// from a browser page at all, which is when there is no Origin header.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host == r.Host
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("playground: rendering %s: %v", name, err)
+	}
+}
+
+var (
+	packageClause = regexp.MustCompile(`(?m)^package \w+$`)
+	runFunc       = regexp.MustCompile(`(?m)^func Run\(\) {$`)
+)
+
+// Source returns the lesson's source rewritten as a standalone main package,
+// ready to be run by the playground.
+func Source(l lessons.Lesson) (string, error) {
+	srcs, err := l.Sources()
+	if err != nil {
+		return "", err
+	}
+	for _, path := range srcs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if runFunc.Match(data) {
+			return Standalone(string(data)), nil
+		}
+	}
+	return "", errors.New("lesson has no Run function")
+}
+
+// Standalone rewrites the source of a lesson package into a main package
+// whose main function is the lesson's Run function.
+func Standalone(src string) string {
+	src = packageClause.ReplaceAllLiteralString(src, "package main")
+	return runFunc.ReplaceAllLiteralString(src, "func main() {")
+}
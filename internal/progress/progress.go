@@ -0,0 +1,157 @@
+// Package progress keeps track of the lessons a learner has completed in a
+// JSON state file.
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lesson is the progress made on a single lesson.
+type Lesson struct {
+	Completed   bool                 `json:"completed"`
+	CompletedAt time.Time            `json:"completed_at"`
+	LastWorked  time.Time            `json:"last_worked"`
+	Exercises   map[string]*Exercise `json:"exercises,omitempty"`
+}
+
+// Attempts returns the number of attempts made on the lesson's exercises.
+func (l *Lesson) Attempts() int {
+	n := 0
+	for _, e := range l.Exercises {
+		n += e.Attempts
+	}
+	return n
+}
+
+// Exercise is the progress made on a single exercise, identified by the name
+// of its checker test.
+type Exercise struct {
+	// Attempts counts the checks up to and including the first passing one.
+	Attempts int  `json:"attempts"`
+	Passed   bool `json:"passed"`
+}
+
+// State is the progress of a learner, keyed by lesson id.
+type State struct {
+	Lessons map[string]*Lesson `json:"lessons"`
+}
+
+// DefaultPath returns the path of the state file under the user's config
+// directory. The LITTLE_ENGINE_PROGRESS environment variable overrides it.
+func DefaultPath() (string, error) {
+	if p := os.Getenv("LITTLE_ENGINE_PROGRESS"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "little-engine", "progress.json"), nil
+}
+
+// Load reads the state file at path. A missing file is an empty state.
+func Load(path string) (*State, error) {
+	s := &State{Lessons: map[string]*Lesson{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Lessons == nil {
+		s.Lessons = map[string]*Lesson{}
+	}
+	return s, nil
+}
+
+// Save writes the state to path. The file is replaced atomically so that an
+// interrupted save doesn't lose the previous progress.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".progress-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *State) lesson(id string) *Lesson {
+	l, ok := s.Lessons[id]
+	if !ok {
+		l = &Lesson{Exercises: map[string]*Exercise{}}
+		s.Lessons[id] = l
+	}
+	if l.Exercises == nil {
+		l.Exercises = map[string]*Exercise{}
+	}
+	return l
+}
+
+// RecordRun records that the lesson was run at the given time.
+func (s *State) RecordRun(id string, at time.Time) {
+	s.lesson(id).LastWorked = at
+}
+
+// RecordCheck records a check of the lesson's exercises. results maps the
+// name of every check to whether it passed. The lesson is completed once
+// all of its checks pass.
+func (s *State) RecordCheck(id string, results map[string]bool, at time.Time) {
+	l := s.lesson(id)
+	l.LastWorked = at
+	for name, passed := range results {
+		e, ok := l.Exercises[name]
+		if !ok {
+			e = &Exercise{}
+			l.Exercises[name] = e
+		}
+		if e.Passed {
+			continue
+		}
+		e.Attempts++
+		e.Passed = passed
+	}
+
+	if l.Completed || len(results) == 0 {
+		return
+	}
+	for _, passed := range results {
+		if !passed {
+			return
+		}
+	}
+	l.Completed = true
+	l.CompletedAt = at
+}
+
+// Next returns the first lesson of ids, in order, that isn't completed. It
+// returns "" once every lesson is completed.
+func (s *State) Next(ids []string) string {
+	for _, id := range ids {
+		if l, ok := s.Lessons[id]; !ok || !l.Completed {
+			return id
+		}
+	}
+	return ""
+}
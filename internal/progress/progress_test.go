@@ -0,0 +1,59 @@
+package progress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordCheck(t *testing.T) {
+	s := &State{Lessons: map[string]*Lesson{}}
+	t0 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	s.RecordCheck("0013", map[string]bool{"TestSum": false, "TestFibonacci": false}, t0)
+	s.RecordCheck("0013", map[string]bool{"TestSum": true, "TestFibonacci": false}, t0.Add(time.Minute))
+	l := s.Lessons["0013"]
+	if l.Completed {
+		t.Fatal("lesson completed with a failing check")
+	}
+
+	t1 := t0.Add(time.Hour)
+	s.RecordCheck("0013", map[string]bool{"TestSum": true, "TestFibonacci": true}, t1)
+	if !l.Completed || !l.CompletedAt.Equal(t1) || !l.LastWorked.Equal(t1) {
+		t.Errorf("lesson = %+v, want completed at %v", l, t1)
+	}
+	if got := l.Exercises["TestSum"].Attempts; got != 2 {
+		t.Errorf("TestSum attempts = %d, want 2", got)
+	}
+	if got := l.Exercises["TestFibonacci"].Attempts; got != 3 {
+		t.Errorf("TestFibonacci attempts = %d, want 3", got)
+	}
+	if got := l.Attempts(); got != 5 {
+		t.Errorf("lesson attempts = %d, want 5", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "progress.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.RecordCheck("0001", map[string]bool{"TestGreet": true}, at)
+	s.RecordRun("0002", at)
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Lessons["0001"].Completed || !s.Lessons["0002"].LastWorked.Equal(at) {
+		t.Errorf("loaded state %+v doesn't match the saved one", s.Lessons)
+	}
+	if got := s.Next([]string{"0001", "0002", "0003"}); got != "0002" {
+		t.Errorf("Next = %q, want 0002", got)
+	}
+}
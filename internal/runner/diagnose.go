@@ -0,0 +1,331 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+// SnippetDir is the directory of a lesson holding its snippets.
+const SnippetDir = "snippets"
+
+// Snippet is a small program showing a mistake the lesson warns about, like
+// a deadlock, kept in the snippets directory of the lesson. Snippets are
+// excluded from the build with a "//go:build ignore" line and are run with
+// Diagnose.
+type Snippet struct {
+	Name string // file name without .go, e.g. "deadlock"
+	Path string
+	Doc  string // the comment above the package clause
+}
+
+// ErrNoSnippet is reported for snippet names a lesson doesn't have.
+var ErrNoSnippet = errors.New("no such snippet")
+
+// Snippets returns the snippets of the lesson ordered by name.
+func Snippets(l lessons.Lesson) ([]Snippet, error) {
+	paths, err := filepath.Glob(filepath.Join(l.Dir, SnippetDir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var ss []Snippet
+	for _, p := range paths {
+		f, err := parser.ParseFile(token.NewFileSet(), p, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		s := Snippet{Name: strings.TrimSuffix(filepath.Base(p), ".go"), Path: p}
+		if f.Doc != nil {
+			s.Doc = strings.Join(strings.Fields(f.Doc.Text()), " ")
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+// FindSnippet returns the snippet of the lesson with the given name.
+func FindSnippet(l lessons.Lesson, name string) (Snippet, error) {
+	ss, err := Snippets(l)
+	if err != nil {
+		return Snippet{}, err
+	}
+	for _, s := range ss {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Snippet{}, fmt.Errorf("%w %q in %s", ErrNoSnippet, name, l.Title())
+}
+
+// Outcome is how a diagnosed program ended.
+type Outcome int
+
+const (
+	Finished   Outcome = iota // the program exited on its own
+	Deadlocked                // the runtime found every goroutine blocked
+	Panicked                  // a goroutine panicked
+	Crashed                   // the runtime stopped the program with a fatal error
+	TimedOut                  // the watchdog stopped the program
+)
+
+var outcomes = [...]string{"finished", "deadlocked", "panicked", "crashed", "timed out"}
+
+func (o Outcome) String() string {
+	return outcomes[o]
+}
+
+// Diagnosis is what went wrong in a run of a snippet.
+type Diagnosis struct {
+	Snippet  Snippet
+	Outcome  Outcome
+	ExitCode int
+	// Output is what the program printed to stdout.
+	Output string
+	// Message is the panic or fatal error, e.g. "send on closed channel".
+	Message string
+	// Goroutines are the goroutines of the program that were running code
+	// of the snippet when it was stopped.
+	Goroutines []Goroutine
+	// Trace is the raw goroutine dump printed by the runtime.
+	Trace string
+}
+
+// Goroutine is a goroutine of a diagnosed program.
+type Goroutine struct {
+	ID    int
+	State string // e.g. "chan send" or "sync.WaitGroup.Wait"
+	// Func and Line are the function of the snippet the goroutine was in
+	// and the file and line it had reached, e.g. "deadlock.go:10".
+	Func string
+	Line string
+}
+
+// Diagnose builds the snippet and runs it. If it's still running after
+// watchdog it is sent SIGQUIT, which makes the runtime print every goroutine
+// before exiting. The goroutine dump of a panic, a fatal error or the
+// watchdog is turned into the diagnosis.
+func Diagnose(ctx context.Context, s Snippet, watchdog time.Duration) (Diagnosis, error) {
+	d := Diagnosis{Snippet: s}
+	dir, err := os.MkdirTemp("", "little-engine-diagnose-")
+	if err != nil {
+		return d, err
+	}
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, s.Name)
+	build := exec.CommandContext(ctx, "go", "build", "-o", bin, filepath.Base(s.Path))
+	build.Dir = filepath.Dir(s.Path)
+	if out, err := build.CombinedOutput(); err != nil {
+		return d, fmt.Errorf("building %s: %v\n%s", s.Name, err, out)
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, watchdog)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(wctx, bin)
+	cmd.Dir = filepath.Dir(filepath.Dir(s.Path))
+	cmd.Env = append(os.Environ(), "GOTRACEBACK=all")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Cancel = func() error {
+		return quit(cmd.Process)
+	}
+	cmd.WaitDelay = 2 * time.Second
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		d.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return d, err
+	}
+	d.Output = stdout.String()
+	d.Trace = stderr.String()
+	diagnose(&d, wctx.Err() == context.DeadlineExceeded)
+	return d, nil
+}
+
+var (
+	// goroutine 1 [chan send]:
+	// goroutine 1 gp=0xc000002380 m=nil [chan receive, 1 minutes]:
+	goroutineHeader = regexp.MustCompile(`^goroutine (\d+) (?:.* )?\[([^,\]]*)[^\]]*\]:$`)
+	// /src/0017_concurrency/snippets/deadlock.go:10 +0x36
+	frameLocation = regexp.MustCompile(`^\t(.*\.go):(\d+)`)
+)
+
+// diagnose fills in the outcome, message and goroutines of d from its
+// trace.
+func diagnose(d *Diagnosis, timedOut bool) {
+	first, _, _ := strings.Cut(d.Trace, "\n")
+	switch {
+	case timedOut:
+		d.Outcome = TimedOut
+	case strings.HasPrefix(first, "fatal error: all goroutines are asleep"):
+		d.Outcome = Deadlocked
+		d.Message = strings.TrimPrefix(first, "fatal error: ")
+	case strings.HasPrefix(first, "panic: "):
+		d.Outcome = Panicked
+		d.Message = strings.TrimPrefix(first, "panic: ")
+		// panic: send on closed channel [recovered]
+		d.Message, _, _ = strings.Cut(d.Message, " [recovered]")
+	case strings.HasPrefix(first, "fatal error: "):
+		d.Outcome = Crashed
+		d.Message = strings.TrimPrefix(first, "fatal error: ")
+	default:
+		d.Outcome = Finished
+	}
+
+	base := filepath.Base(d.Snippet.Path)
+	for _, block := range strings.Split(d.Trace, "\n\n") {
+		lines := strings.Split(block, "\n")
+		m := goroutineHeader.FindStringSubmatch(lines[0])
+		if m == nil {
+			continue
+		}
+		g := Goroutine{State: m[2]}
+		g.ID, _ = strconv.Atoi(m[1])
+		// the innermost frame in the snippet, skipping "created by" lines
+		for i := 2; i < len(lines); i++ {
+			if strings.HasPrefix(lines[i-1], "created by ") {
+				break
+			}
+			loc := frameLocation.FindStringSubmatch(lines[i])
+			if loc != nil && filepath.Base(loc[1]) == base {
+				g.Func = funcName(lines[i-1])
+				g.Line = base + ":" + loc[2]
+				break
+			}
+		}
+		if g.Line != "" {
+			d.Goroutines = append(d.Goroutines, g)
+		}
+	}
+}
+
+// funcName returns the function of a stack frame line such as
+// "main.main.func1(0x2)".
+func funcName(frame string) string {
+	if i := strings.LastIndex(frame, "("); i > 0 {
+		return frame[:i]
+	}
+	return frame
+}
+
+// problems explain the panics and fatal errors of concurrent programs.
+var problems = map[string]string{
+	"all goroutines are asleep - deadlock!": "Every goroutine is waiting for another one, so the program can never " +
+		"continue. The runtime noticed and stopped it.",
+	"send on closed channel": "A goroutine sent on a channel after it was closed. Closing a channel tells " +
+		"the receivers that no more values are coming, so only the sender closes it, after its last send. " +
+		"With several senders, close it once all of them are done, e.g. after wg.Wait().",
+	"close of closed channel": "A channel was closed twice. Only one goroutine may close a channel: the " +
+		"single sender, or the one that waits for all the senders to finish.",
+	"close of nil channel": "A nil channel was closed. Create channels with make before using them.",
+	"concurrent map writes": "Two goroutines wrote a map at the same time. Maps aren't safe for " +
+		"concurrent use, guard them with a sync.Mutex or sync.RWMutex.",
+	"concurrent map read and map write": "A goroutine read a map while another one wrote it. Maps aren't " +
+		"safe for concurrent use, guard them with a sync.Mutex or sync.RWMutex.",
+	"sync: negative WaitGroup counter": "Done was called more often than Add added to the WaitGroup.",
+	"sync: unlock of unlocked mutex": "A mutex was unlocked that wasn't locked. Lock and Unlock come in " +
+		"pairs, best with a deferred Unlock right after the Lock.",
+}
+
+// states explain what a goroutine in a state of the goroutine dump is
+// waiting for.
+var states = map[string]string{
+	"chan send": "is waiting to send on a channel. A send on an unbuffered channel waits until " +
+		"another goroutine receives, one on a buffered channel until there's room.",
+	"chan send (nil chan)": "is sending on a nil channel, which blocks forever. Create channels with make.",
+	"chan receive": "is waiting to receive from a channel. It waits until another goroutine sends on " +
+		"the channel or closes it.",
+	"chan receive (nil chan)": "is receiving from a nil channel, which blocks forever. Create channels with make.",
+	"select":                  "is waiting in a select until one of its channels is ready.",
+	"select (no cases)":       "is in a select without cases, which blocks forever.",
+	"sync.WaitGroup.Wait": "is waiting for a WaitGroup. Wait returns once Done was called as often as " +
+		"Add added, so a goroutine returned without calling Done or Add added too much. " +
+		"Call defer wg.Done() first thing in the goroutine.",
+	"sync.Mutex.Lock": "is waiting for a mutex that's never unlocked. Unlock with defer right after " +
+		"locking, and don't lock a mutex the goroutine already holds.",
+	"sync.RWMutex.Lock":  "is waiting for the write lock of an RWMutex while readers or another writer hold it.",
+	"sync.RWMutex.RLock": "is waiting for the read lock of an RWMutex while a writer holds or waits for it.",
+	"sync.Cond.Wait":     "is waiting on a sync.Cond that nobody signals.",
+	"sleep":              "is sleeping.",
+	"running":            "was running.",
+	"runnable":           "was ready to run.",
+}
+
+// Report writes the diagnosis in the terms of the lessons.
+func (d Diagnosis) Report(w io.Writer) {
+	switch d.Outcome {
+	case Finished:
+		fmt.Fprintf(w, "%s finished with exit status %d.\n", d.Snippet.Name, d.ExitCode)
+		return
+	case TimedOut:
+		fmt.Fprintln(w, "TIMED OUT: the program was still running when the watchdog stopped it.")
+		fmt.Fprintln(w, Wrap("The runtime only reports a deadlock when every goroutine is blocked. "+
+			"A goroutine that sleeps, ticks or waits for a timer keeps the program alive, so blocked "+
+			"goroutines next to it go unnoticed. Look for the goroutines below that wait for "+
+			"something that never happens."))
+	case Deadlocked:
+		fmt.Fprintln(w, "DEADLOCK:", d.Message)
+	case Panicked:
+		fmt.Fprintln(w, "PANIC:", d.Message)
+	case Crashed:
+		fmt.Fprintln(w, "FATAL ERROR:", d.Message)
+	}
+	if why, ok := problems[d.Message]; ok {
+		fmt.Fprintln(w, Wrap(why))
+	}
+	for _, g := range d.Goroutines {
+		name := fmt.Sprintf("goroutine %d", g.ID)
+		if g.ID == 1 {
+			name += " (main)"
+		}
+		what, ok := states[g.State]
+		if !ok {
+			what = "is blocked (" + g.State + ")."
+		}
+		if d.Outcome == Panicked && g.State == "running" {
+			what = "panicked here."
+		}
+		fmt.Fprintf(w, "\n%s in %s at %s\n", name, g.Func, g.Line)
+		fmt.Fprintln(w, indent(Wrap(what)))
+	}
+}
+
+// Wrap breaks s into lines of at most 76 characters.
+func Wrap(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, word := range strings.Fields(s) {
+		if n > 0 && n+1+len(word) > 76 {
+			b.WriteByte('\n')
+			n = 0
+		} else if n > 0 {
+			b.WriteByte(' ')
+			n++
+		}
+		b.WriteString(word)
+		n += len(word)
+	}
+	return b.String()
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
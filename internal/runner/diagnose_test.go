@@ -0,0 +1,99 @@
+package runner_test
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/runner"
+)
+
+func concurrencyLesson(t *testing.T) lessons.Lesson {
+	t.Helper()
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := lessons.Find(ls, "0017")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestSnippets(t *testing.T) {
+	l := concurrencyLesson(t)
+	ss, err := runner.Snippets(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range ss {
+		names = append(names, s.Name)
+		if s.Doc == "" {
+			t.Errorf("snippet %s has no doc comment", s.Name)
+		}
+	}
+	if got, want := strings.Join(names, " "), "close_twice deadlock missing_done send_on_closed stuck_worker"; got != want {
+		t.Errorf("snippets are %s, want %s", got, want)
+	}
+	if _, err := runner.FindSnippet(l, "livelock"); err == nil {
+		t.Error("FindSnippet found a snippet that doesn't exist")
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds every snippet")
+	}
+	l := concurrencyLesson(t)
+	tests := []struct {
+		snippet string
+		outcome runner.Outcome
+		message string
+		// state and line of the first goroutine in the snippet
+		state, line string
+	}{
+		{"deadlock", runner.Deadlocked, "all goroutines are asleep - deadlock!", "chan send", "deadlock.go:10"},
+		{"missing_done", runner.Deadlocked, "all goroutines are asleep - deadlock!", "sync.WaitGroup.Wait", "missing_done.go:23"},
+		{"send_on_closed", runner.Panicked, "send on closed channel", "running", "send_on_closed.go:12"},
+		{"close_twice", runner.Panicked, "close of closed channel", "running", "close_twice.go:19"},
+		{"stuck_worker", runner.TimedOut, "", "chan receive", "stuck_worker.go:24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.snippet, func(t *testing.T) {
+			if tt.outcome == runner.TimedOut && (runtime.GOOS == "windows" || runtime.GOOS == "plan9") {
+				t.Skip("no goroutine dump without SIGQUIT")
+			}
+			s, err := runner.FindSnippet(l, tt.snippet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := runner.Diagnose(context.Background(), s, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Outcome != tt.outcome || d.Message != tt.message {
+				t.Fatalf("diagnosed %v %q, want %v %q\n%s", d.Outcome, d.Message, tt.outcome, tt.message, d.Trace)
+			}
+			if len(d.Goroutines) == 0 {
+				t.Fatalf("no goroutines in the snippet\n%s", d.Trace)
+			}
+			if g := d.Goroutines[0]; g.State != tt.state || g.Line != tt.line {
+				t.Errorf("goroutine %d is %q at %s, want %q at %s", g.ID, g.State, g.Line, tt.state, tt.line)
+			}
+			var report strings.Builder
+			d.Report(&report)
+			if strings.Contains(report.String(), "is blocked (") {
+				t.Errorf("report doesn't explain a goroutine state:\n%s", report.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package runner_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	concurrency "github.com/ssukruth/little-engine-go/0017_concurrency"
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/runner"
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+// Run "go test ./internal/runner -update" to regenerate the golden files
+// after intentionally changing what a lesson prints.
+var update = flag.Bool("update", false, "update the lesson golden files")
+
+// stdin is fed to lessons that read from standard input.
+const stdin = "gopher\n"
+
+// normalizers remove the parts of a lesson's output that change from run to
+// run. Every lesson output additionally goes through maskAddresses.
+var normalizers = map[string]func(string) string{
+	// os.Args holds the path of the test binary
+	"0006": replace(regexp.MustCompile(`(?m)^os.Args: \[.*\]$`), "os.Args: [<args>]"),
+	// map iteration order is random
+	"0010": sortRuns(regexp.MustCompile(`^key: `)),
+	// file permissions depend on the umask and every run gets a new scratchpad
+	"0011": chain(
+		replace(regexp.MustCompile(`(?m)^Permission: .*$`), "Permission: <mode>"),
+		replace(regexp.MustCompile(`\S*little-engine-files-\d+`), "<scratchpad>"),
+	),
+	// goroutine scheduling decides the order of lines within each section
+	// and the value of n in the data race example
+	"0017": chain(
+		replaceFirst(regexp.MustCompile(`n value is: +-?\d+\nIs n value what we expected\? (true|false)`),
+			"n value is: <racy>\nIs n value what we expected? <racy>"),
+		sortParagraphs,
+	),
+}
+
+// clocks make the lessons that wait on a clock.Clock wait on the given one
+// instead of the real clock. They return a function restoring the real one.
+var clocks = map[string]func(clock.Clock) func(){
+	"0017": concurrency.SetClock,
+}
+
+// racy lessons demonstrate a data race on purpose, which fails the test
+// under the race detector.
+var racy = map[string]bool{"0017": true}
+
+func TestGolden(t *testing.T) {
+	root, err := lessons.FindRoot(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := lessons.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range ls {
+		t.Run(l.Title(), func(t *testing.T) {
+			if raceEnabled && racy[l.ID] {
+				t.Skip("the lesson demonstrates a data race")
+			}
+			// lessons must wait for every goroutine they start
+			leakcheck.Check(t)
+			if setClock, ok := clocks[l.ID]; ok {
+				// time passes whenever the lesson only waits for it
+				fake := clock.NewFake(time.Time{})
+				defer setClock(fake)()
+				stop := make(chan struct{})
+				defer close(stop)
+				go fake.AutoAdvance(stop)
+			}
+			res := runner.Run(l, strings.NewReader(stdin), io.Discard)
+			if !res.Passed() {
+				t.Fatalf("lesson failed: %v\n%s", res.Err, res.Output)
+			}
+			got := normalize(l.ID, res.Output)
+
+			golden := filepath.Join(l.Dir, "testdata", "output.golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s (run with -update if intended)\n%s", golden, diff(string(want), got))
+			}
+		})
+	}
+}
+
+func normalize(id, out string) string {
+	out = maskAddresses(out)
+	if n, ok := normalizers[id]; ok {
+		out = n(out)
+	}
+	return out
+}
+
+var addrPattern = regexp.MustCompile(`0x[0-9a-f]{6,}`)
+
+// maskAddresses numbers memory addresses in order of appearance, so that two
+// pointers to the same variable still print the same value.
+func maskAddresses(s string) string {
+	seen := map[string]string{}
+	return addrPattern.ReplaceAllStringFunc(s, func(addr string) string {
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = fmt.Sprintf("<addr%d>", len(seen)+1)
+		}
+		return seen[addr]
+	})
+}
+
+func chain(fs ...func(string) string) func(string) string {
+	return func(s string) string {
+		for _, f := range fs {
+			s = f(s)
+		}
+		return s
+	}
+}
+
+func replace(re *regexp.Regexp, repl string) func(string) string {
+	return func(s string) string {
+		return re.ReplaceAllLiteralString(s, repl)
+	}
+}
+
+func replaceFirst(re *regexp.Regexp, repl string) func(string) string {
+	return func(s string) string {
+		loc := re.FindStringIndex(s)
+		if loc == nil {
+			return s
+		}
+		return s[:loc[0]] + repl + s[loc[1]:]
+	}
+}
+
+// sortRuns sorts each run of consecutive lines matching re.
+func sortRuns(re *regexp.Regexp) func(string) string {
+	return func(s string) string {
+		lines := strings.Split(s, "\n")
+		for i := 0; i < len(lines); {
+			j := i
+			for j < len(lines) && re.MatchString(lines[j]) {
+				j++
+			}
+			if j > i {
+				sort.Strings(lines[i:j])
+				i = j
+			} else {
+				i++
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// sortParagraphs sorts the lines of each blank line separated paragraph.
+func sortParagraphs(s string) string {
+	return sortRuns(regexp.MustCompile(`.`))(s)
+}
+
+// diff returns the first differing line of want and got.
+func diff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %q\n   got: %q", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// quit kills p. There's no SIGQUIT outside of unix, so the diagnosis of a
+// program stopped by the watchdog has no goroutine dump.
+func quit(p *os.Process) error {
+	return p.Kill()
+}
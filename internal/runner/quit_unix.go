@@ -0,0 +1,14 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// quit stops p with SIGQUIT, on which the go runtime prints the stacks of
+// all goroutines before exiting.
+func quit(p *os.Process) error {
+	return p.Signal(syscall.SIGQUIT)
+}
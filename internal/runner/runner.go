@@ -0,0 +1,199 @@
+// Package runner executes lessons in-process and captures what they print,
+// and runs the snippets of lessons that fail on purpose to explain why.
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+)
+
+// ErrNotRegistered is reported for lesson directories that have no entry
+// point in the lessons registry.
+var ErrNotRegistered = errors.New("lesson is not registered")
+
+// Result is the outcome of a single lesson run.
+type Result struct {
+	Lesson   lessons.Lesson
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// Passed reports whether the lesson ran to completion without panicking.
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Lessons print to os.Stdout, read os.Stdin and use paths relative to their
+// directory, all of which are process wide, so only one lesson may run at a
+// time.
+var mu sync.Mutex
+
+// Run executes the lesson from within its directory. Everything the lesson
+// prints to stdout is copied to w and recorded in the result. When stdin is
+// not nil the lesson reads it in place of os.Stdin. A panic in the lesson is
+// recovered and reported as the result's error.
+func Run(l lessons.Lesson, stdin io.Reader, w io.Writer) Result {
+	res := Result{Lesson: l}
+	if l.Run == nil {
+		res.Err = ErrNotRegistered
+		return res
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if err := os.Chdir(l.Dir); err != nil {
+		res.Err = err
+		return res
+	}
+	defer os.Chdir(wd)
+
+	if stdin != nil {
+		restore, err := feed(stdin)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		defer restore()
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	var buf bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(w, &buf), pr)
+		close(copied)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = pw
+	start := time.Now()
+	res.Err = call(l.Run)
+	res.Duration = time.Since(start)
+	os.Stdout = stdout
+
+	pw.Close()
+	<-copied
+	pr.Close()
+	res.Output = buf.String()
+	return res
+}
+
+// feed replaces os.Stdin with r. A file is used as it is; any other reader
+// is copied into a pipe by a pump. The returned function puts the original
+// back; input in the pipe that the lesson didn't read is dropped.
+func feed(r io.Reader) (restore func(), err error) {
+	stdin := os.Stdin
+	if f, ok := r.(*os.File); ok {
+		os.Stdin = f
+		return func() { os.Stdin = stdin }, nil
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p := pumpFor(r)
+	stop, fed := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(fed)
+		p.fill(pw, stop)
+		pw.Close()
+	}()
+	os.Stdin = pr
+	return func() {
+		os.Stdin = stdin
+		close(stop)
+		pr.Close()
+		<-fed
+	}, nil
+}
+
+// pump reads from a reader for the lessons fed from it, one after the other.
+// A reader like the interactive line editor blocks in Read until the learner
+// types a line. If the lesson ends in the meantime, the Read is left running
+// and the line it returns goes to the next lesson fed from the reader, instead
+// of being swallowed.
+type pump struct {
+	r       io.Reader
+	reads   chan readResult // the result of the Read in flight
+	reading bool
+	pending []byte // read but not yet written to a lesson
+	err     error  // the error that ended reading
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// lastPump is the pump of the reader fed to the last lesson, which run
+// --all feeds to every lesson. It's only used with mu held.
+var lastPump *pump
+
+func pumpFor(r io.Reader) *pump {
+	if lastPump == nil || !reflect.TypeOf(r).Comparable() || lastPump.r != r {
+		lastPump = &pump{r: r, reads: make(chan readResult, 1)}
+	}
+	return lastPump
+}
+
+// fill writes what it reads to w until the reader returns an error, writing
+// to w fails or stop is closed.
+func (p *pump) fill(w io.Writer, stop <-chan struct{}) {
+	for {
+		if len(p.pending) == 0 {
+			if p.err != nil {
+				return
+			}
+			if !p.reading {
+				p.reading = true
+				go func() {
+					buf := make([]byte, 4096)
+					n, err := p.r.Read(buf)
+					p.reads <- readResult{buf[:n], err}
+				}()
+			}
+			select {
+			case res := <-p.reads:
+				p.reading = false
+				p.pending, p.err = res.data, res.err
+				continue
+			case <-stop:
+				return
+			}
+		}
+		n, err := w.Write(p.pending)
+		p.pending = p.pending[n:]
+		if err != nil {
+			return
+		}
+	}
+}
+
+func call(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,72 @@
+package runner_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/internal/lessons"
+	"github.com/ssukruth/little-engine-go/internal/runner"
+)
+
+func TestRunKeepsLineReadAfterLessonEnded(t *testing.T) {
+	// like the line editor, r blocks until the learner types a line
+	r, w := io.Pipe()
+	defer w.Close()
+	dir := t.TempDir()
+
+	// the first lesson ends without reading while its input is waited for
+	first := lessons.Lesson{ID: "0001", Name: "first", Dir: dir, Run: func() {}}
+	if res := runner.Run(first, r, io.Discard); !res.Passed() {
+		t.Fatal(res.Err)
+	}
+	go func() {
+		io.WriteString(w, "gopher\n")
+		w.Close()
+	}()
+
+	var line string
+	second := lessons.Lesson{ID: "0002", Name: "second", Dir: dir, Run: func() {
+		s := bufio.NewScanner(os.Stdin)
+		s.Scan()
+		line = s.Text()
+		fmt.Println("read", line)
+	}}
+	if res := runner.Run(second, r, io.Discard); !res.Passed() {
+		t.Fatal(res.Err)
+	}
+	if line != "gopher" {
+		t.Errorf("second lesson read %q, want the line typed after the first one ended", line)
+	}
+}
+
+func TestRunFeedsFile(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fmt.Fprint(f, "one\ntwo\n")
+	f.Seek(0, io.SeekStart)
+
+	var got []string
+	l := lessons.Lesson{ID: "0001", Name: "read", Dir: t.TempDir(), Run: func() {
+		var word string
+		fmt.Fscanln(os.Stdin, &word)
+		got = append(got, word)
+	}}
+	stdin := os.Stdin
+	for i := 0; i < 2; i++ {
+		if res := runner.Run(l, f, io.Discard); !res.Passed() {
+			t.Fatal(res.Err)
+		}
+	}
+	if os.Stdin != stdin {
+		t.Error("os.Stdin wasn't restored")
+	}
+	if len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("lessons read %q, want one line each", got)
+	}
+}
@@ -0,0 +1,128 @@
+// Package clock lets code that waits for time to pass run on a fake clock in
+// tests.
+//
+// Code takes a Clock instead of calling the time package directly. Real()
+// behaves like the time package; a Fake only moves when it is told to, so a
+// test can step through sleeps and timeouts instantly and in a known order.
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the part of the time package that waits.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a time.Timer, with its channel behind a method.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a time.Ticker, with its channel behind a method.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real returns the clock of the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// Sleep pauses for d on c or until ctx is done, whichever comes first, and
+// returns ctx.Err() in the latter case.
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithTimeout is context.WithTimeout measuring the timeout on c. The returned
+// context's Err is context.DeadlineExceeded once the timeout has passed.
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+	deadline := c.Now().Add(d)
+	if cur, ok := parent.Deadline(); ok && cur.Before(deadline) {
+		// the parent gives up first anyway
+		return context.WithCancel(parent)
+	}
+	ctx := &timerCtx{Context: parent, deadline: deadline, done: make(chan struct{})}
+	stop := context.AfterFunc(parent, func() { ctx.cancel(parent.Err()) })
+	t := c.NewTimer(d)
+	go func() {
+		select {
+		case <-t.C():
+			ctx.cancel(context.DeadlineExceeded)
+		case <-ctx.done:
+		}
+		t.Stop()
+		stop()
+	}()
+	return ctx, func() { ctx.cancel(context.Canceled) }
+}
+
+// timerCtx is a context cancelled by the timer of a Clock. It has its own
+// Done channel, so that contexts derived from it see its Err rather than
+// that of the parent.
+type timerCtx struct {
+	context.Context // the parent, for Value
+	deadline        time.Time
+	done            chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *timerCtx) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *timerCtx) Done() <-chan struct{}       { return c.done }
+
+func (c *timerCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *timerCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
@@ -0,0 +1,189 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestFakeTimers(t *testing.T) {
+	leakcheck.Check(t)
+	f := NewFake(time.Time{})
+	start := f.Now()
+	woke := make(chan string)
+	for _, d := range []time.Duration{3 * time.Second, time.Second, 2 * time.Second} {
+		go func() {
+			at := <-f.After(d)
+			woke <- fmt.Sprintf("%v at %v", d, at.Sub(start))
+		}()
+	}
+	f.BlockUntil(3)
+	f.Advance(1500 * time.Millisecond)
+	if got := <-woke; got != "1s at 1s" {
+		t.Errorf("first to wake was %s", got)
+	}
+	if got := f.Since(start); got != 1500*time.Millisecond {
+		t.Errorf("Since = %v after Advance(1.5s)", got)
+	}
+	select {
+	case got := <-woke:
+		t.Errorf("%s woke up early", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+	f.Advance(5 * time.Second)
+	got := []string{<-woke, <-woke}
+	if got[0] > got[1] {
+		got[0], got[1] = got[1], got[0]
+	}
+	if fmt.Sprint(got) != "[2s at 2s 3s at 3s]" {
+		t.Errorf("woke %v", got)
+	}
+	if got := f.Since(start); got != 6500*time.Millisecond {
+		t.Errorf("Since = %v, want 6.5s", got)
+	}
+}
+
+func TestFakeAdvanceNext(t *testing.T) {
+	f := NewFake(time.Time{})
+	start := f.Now()
+	a, b := f.NewTimer(2*time.Second), f.After(time.Second)
+	if !f.AdvanceNext() {
+		t.Fatal("AdvanceNext found no timer")
+	}
+	if got := (<-b).Sub(start); got != time.Second {
+		t.Errorf("After fired at %v", got)
+	}
+	select {
+	case <-a.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	if !a.Stop() {
+		t.Error("Stop of a waiting timer returned false")
+	}
+	if f.AdvanceNext() {
+		t.Error("AdvanceNext fired a stopped timer")
+	}
+	if a.Reset(time.Second) {
+		t.Error("Reset of a stopped timer returned true")
+	}
+	f.Advance(time.Second)
+	if got := (<-a.C()).Sub(start); got != 2*time.Second {
+		t.Errorf("reset timer fired at %v", got)
+	}
+	select {
+	case <-f.After(0):
+	default:
+		t.Error("After(0) didn't fire immediately")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(time.Time{})
+	start := f.Now()
+	tk := f.NewTicker(time.Second)
+	var ticks []time.Duration
+	for i := 0; i < 3; i++ {
+		f.Advance(time.Second)
+		ticks = append(ticks, (<-tk.C()).Sub(start))
+	}
+	// ticks a slow receiver misses are dropped
+	f.Advance(3 * time.Second)
+	ticks = append(ticks, (<-tk.C()).Sub(start))
+	tk.Reset(500 * time.Millisecond)
+	f.Advance(500 * time.Millisecond)
+	ticks = append(ticks, (<-tk.C()).Sub(start))
+	tk.Stop()
+	if f.Waiters() != 0 {
+		t.Error("stopped ticker is still waiting")
+	}
+	if got, want := fmt.Sprint(ticks), "[1s 2s 3s 4s 6.5s]"; got != want {
+		t.Errorf("ticks at %s, want %s", got, want)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	leakcheck.Check(t)
+	f := NewFake(time.Time{})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- Sleep(ctx, f, time.Hour) }()
+	f.BlockUntil(1)
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Sleep = %v, want Canceled", err)
+	}
+	if f.Waiters() != 0 {
+		t.Error("cancelled Sleep left its timer behind")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	leakcheck.Check(t)
+	f := NewFake(time.Time{})
+	ctx, cancel := WithTimeout(context.Background(), f, time.Second)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	if d, ok := ctx.Deadline(); !ok || d != f.Now().Add(time.Second) {
+		t.Errorf("Deadline = %v, %v", d, ok)
+	}
+	f.Advance(999 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("context done before its deadline")
+	}
+	f.Advance(time.Millisecond)
+	<-child.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || !errors.Is(child.Err(), context.DeadlineExceeded) {
+		t.Errorf("Err = %v, child %v, want DeadlineExceeded", ctx.Err(), child.Err())
+	}
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = WithTimeout(parent, f, time.Second)
+	defer cancel()
+	cancelParent()
+	<-ctx.Done()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("Err after cancelling the parent = %v", ctx.Err())
+	}
+
+	ctx, cancel = WithTimeout(context.Background(), f, time.Second)
+	cancel()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("Err after cancel = %v", ctx.Err())
+	}
+}
+
+func TestAutoAdvance(t *testing.T) {
+	leakcheck.Check(t)
+	f := NewFake(time.Time{})
+	start := f.Now()
+	stop := make(chan struct{})
+	defer close(stop)
+	go f.AutoAdvance(stop)
+
+	// an hour of sleeps and timeouts, in no time
+	ctx, cancel := WithTimeout(context.Background(), f, time.Hour)
+	defer cancel()
+	results := make(chan string)
+	for _, d := range []time.Duration{20 * time.Minute, 10 * time.Minute, 2 * time.Hour} {
+		go func() {
+			if err := Sleep(ctx, f, d); err != nil {
+				results <- fmt.Sprint(err, " at ", f.Since(start))
+				return
+			}
+			results <- fmt.Sprint(d)
+		}()
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, <-results)
+	}
+	if want := "[10m0s 20m0s context deadline exceeded at 1h0m0s]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package clock
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance, AdvanceNext or
+// AutoAdvance move it. Timers, tickers and sleeps fire in the order of their
+// deadlines, and at the deadline's time, however little real time passes.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // broadcast when timers are added
+	now     time.Time
+	timers  []*fakeTimer // active timers, by deadline
+}
+
+// NewFake returns a fake clock set to start, or to midnight of January 1st
+// 2000 UTC if start is zero.
+func NewFake(start time.Time) *Fake {
+	if start.IsZero() {
+		start = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.NewTimer(d).C()
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(t, d)
+	return t
+}
+
+// NewTicker panics if d isn't positive, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1), period: d}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(t, d)
+	return fakeTicker{t}
+}
+
+// Waiters returns the number of timers, tickers and sleeps waiting for the
+// clock to advance.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil blocks until at least n timers, tickers or sleeps are waiting
+// for the clock to advance. Tests call it to make sure the code under test
+// has reached the point where it waits before advancing the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.changed.Wait()
+	}
+}
+
+// Advance moves the clock forward by d, firing every timer whose deadline is
+// reached on the way in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	for len(f.timers) > 0 && !f.timers[0].when.After(end) {
+		f.fireNext()
+	}
+	f.now = end
+}
+
+// AdvanceNext moves the clock forward to the earliest deadline and fires the
+// timers due at that time. It returns false if no timer is waiting.
+func (f *Fake) AdvanceNext() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.timers) == 0 {
+		return false
+	}
+	when := f.timers[0].when
+	for len(f.timers) > 0 && f.timers[0].when.Equal(when) {
+		f.fireNext()
+	}
+	return true
+}
+
+// AutoAdvance calls AdvanceNext whenever every goroutine of the process is
+// blocked, until stop is closed. That runs a whole program in virtual time
+// without knowing what it waits for: time only passes once nothing else can
+// happen. Busy goroutines that have nothing to do with the clock, such as
+// other parallel tests, delay AutoAdvance but don't break it.
+func (f *Fake) AutoAdvance(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		// a goroutine may be about to wake up another one, so the
+		// process must look idle twice in a row
+		if f.Waiters() > 0 && idle() {
+			runtime.Gosched()
+			if idle() {
+				f.AdvanceNext()
+				continue
+			}
+		}
+		time.Sleep(20 * time.Microsecond)
+	}
+}
+
+// idle reports whether all other goroutines are blocked.
+func idle() bool {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	// the first goroutine is the calling one
+	for _, g := range bytes.Split(buf, []byte("\n\n"))[1:] {
+		// goroutine 7 [chan receive, 2 minutes]:
+		_, state, _ := bytes.Cut(g, []byte(" ["))
+		for _, busy := range []string{"running", "runnable", "syscall"} {
+			if bytes.HasPrefix(state, []byte(busy)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// schedule makes t fire after d. f.mu must be held.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	t.when = f.now.Add(d)
+	if d <= 0 && t.period == 0 {
+		t.fire(f.now)
+		return
+	}
+	i := sort.Search(len(f.timers), func(i int) bool { return f.timers[i].when.After(t.when) })
+	f.timers = append(f.timers, nil)
+	copy(f.timers[i+1:], f.timers[i:])
+	f.timers[i] = t
+	f.changed.Broadcast()
+}
+
+// unschedule removes t and reports whether it was waiting. f.mu must be held.
+func (f *Fake) unschedule(t *fakeTimer) bool {
+	for i, u := range f.timers {
+		if u == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fireNext fires the earliest timer and moves the clock to its deadline.
+// f.mu must be held.
+func (f *Fake) fireNext() {
+	t := f.timers[0]
+	f.timers = f.timers[1:]
+	f.now = t.when
+	t.fire(f.now)
+	if t.period > 0 {
+		f.schedule(t, t.period)
+	}
+}
+
+type fakeTimer struct {
+	clock  *Fake
+	c      chan time.Time
+	when   time.Time
+	period time.Duration // for tickers
+}
+
+// fire sends now on t's channel unless the last value hasn't been received
+// yet, like time.Ticker drops ticks for slow receivers.
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.unschedule(t)
+	if t.period > 0 {
+		t.period = d
+	}
+	t.clock.schedule(t, d)
+	return active
+}
+
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.c }
+func (t fakeTicker) Stop()               { t.t.Stop() }
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.t.Reset(d)
+}
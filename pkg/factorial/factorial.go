@@ -0,0 +1,189 @@
+// Package factorial computes factorials and products of integer ranges of
+// any size with math/big, sequentially or split across goroutines.
+//
+// Both variants multiply the numbers of a range as a balanced tree, which
+// keeps the operands of each multiplication of similar size and is much
+// faster than multiplying one number at a time. The parallel variant splits
+// the range into chunks, multiplies the chunks on separate goroutines and
+// combines the partial products pairwise, again in parallel.
+package factorial
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Product returns lo * (lo+1) * ... * hi, or 1 if hi < lo.
+func Product(lo, hi uint64) *big.Int {
+	if hi < lo {
+		return big.NewInt(1)
+	}
+	return product(lo, hi)
+}
+
+// product multiplies the range as a balanced tree. lo <= hi.
+func product(lo, hi uint64) *big.Int {
+	switch {
+	case lo == hi:
+		return new(big.Int).SetUint64(lo)
+	case hi-lo < 8:
+		// small ranges fit in a few words, multiply them directly
+		r := new(big.Int).SetUint64(lo)
+		var x big.Int
+		// i < hi rather than i <= hi, which would never be false for a hi
+		// of math.MaxUint64
+		for i := lo; i < hi; {
+			i++
+			r.Mul(r, x.SetUint64(i))
+		}
+		return r
+	}
+	mid := lo + (hi-lo)/2
+	return new(big.Int).Mul(product(lo, mid), product(mid+1, hi))
+}
+
+// Factorial returns n!.
+func Factorial(n uint64) *big.Int {
+	return Product(1, n)
+}
+
+// ParallelProduct is Product computed on the given number of goroutines, or
+// on runtime.GOMAXPROCS(0) if workers is less than 1. It returns ctx.Err()
+// if ctx is cancelled before it's done.
+func ParallelProduct(ctx context.Context, lo, hi uint64, workers int) (*big.Int, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if hi < lo {
+		return big.NewInt(1), nil
+	}
+	// more chunks than workers, so that a worker that is done early with
+	// its chunk of small numbers picks up another one
+	chunks := split(lo, hi, 4*workers)
+	partials := make([]*big.Int, len(chunks))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				partials[i] = product(chunks[i][0], chunks[i][1])
+			}
+		}()
+	}
+	for i := range chunks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return combine(ctx, partials)
+}
+
+// split divides [lo, hi] into at most n ranges of about the same length.
+func split(lo, hi uint64, n int) [][2]uint64 {
+	// the length of [0, math.MaxUint64] doesn't fit in a uint64, so size is
+	// worked out from the length minus one
+	span := hi - lo
+	size := span / uint64(n)
+	if span%uint64(n) == uint64(n)-1 {
+		size++
+	}
+	if size == 0 {
+		size = 1
+	}
+	var chunks [][2]uint64
+	for start := lo; ; start += size {
+		end := hi
+		if hi-start >= size && len(chunks) < n-1 {
+			end = start + size - 1
+		}
+		chunks = append(chunks, [2]uint64{start, end})
+		if end == hi {
+			return chunks
+		}
+	}
+}
+
+// combine multiplies the partial products pairwise, each round of pairs in
+// parallel, until one is left.
+func combine(ctx context.Context, ps []*big.Int) (*big.Int, error) {
+	for len(ps) > 1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		next := make([]*big.Int, (len(ps)+1)/2)
+		var wg sync.WaitGroup
+		for i := range next {
+			if 2*i+1 == len(ps) {
+				next[i] = ps[2*i]
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				next[i] = new(big.Int).Mul(ps[2*i], ps[2*i+1])
+			}()
+		}
+		wg.Wait()
+		ps = next
+	}
+	return ps[0], nil
+}
+
+// ParallelFactorial is Factorial computed like ParallelProduct.
+func ParallelFactorial(ctx context.Context, n uint64, workers int) (*big.Int, error) {
+	return ParallelProduct(ctx, 1, n, workers)
+}
+
+// Timing compares computing n! sequentially and in parallel.
+type Timing struct {
+	N          uint64
+	Workers    int
+	Sequential time.Duration
+	Parallel   time.Duration
+}
+
+// Speedup is how many times faster the parallel computation was.
+func (t Timing) Speedup() float64 {
+	return float64(t.Sequential) / float64(t.Parallel)
+}
+
+func (t Timing) String() string {
+	return fmt.Sprintf("%d! sequential %v, %d workers %v, speedup %.2fx",
+		t.N, t.Sequential.Round(time.Microsecond), t.Workers, t.Parallel.Round(time.Microsecond), t.Speedup())
+}
+
+// Measure computes n! both ways and reports how long each took. It returns
+// an error if the results differ, which would be a bug.
+func Measure(ctx context.Context, n uint64, workers int) (Timing, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	t := Timing{N: n, Workers: workers}
+	start := time.Now()
+	seq := Factorial(n)
+	t.Sequential = time.Since(start)
+
+	start = time.Now()
+	par, err := ParallelFactorial(ctx, n, workers)
+	t.Parallel = time.Since(start)
+	if err != nil {
+		return t, err
+	}
+	if seq.Cmp(par) != 0 {
+		return t, fmt.Errorf("factorial: parallel %d! differs from sequential", n)
+	}
+	return t, nil
+}
@@ -0,0 +1,147 @@
+package factorial
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestFactorial(t *testing.T) {
+	want := big.NewInt(1)
+	for n := uint64(0); n <= 100; n++ {
+		if n > 0 {
+			want.Mul(want, new(big.Int).SetUint64(n))
+		}
+		if got := Factorial(n); got.Cmp(want) != 0 {
+			t.Fatalf("%d! = %v, want %v", n, got, want)
+		}
+	}
+	if got := Factorial(25).String(); got != "15511210043330985984000000" {
+		t.Errorf("25! = %s, which doesn't fit in an int64", got)
+	}
+	if got := Product(10, 9); got.Int64() != 1 {
+		t.Errorf("empty product = %v", got)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	ctx := context.Background()
+	ranges := [][2]uint64{{1, 1}, {1, 2}, {5, 5}, {3, 17}, {1, 1000}, {999, 5000}, {1, 20000}}
+	for _, r := range ranges {
+		want := Product(r[0], r[1])
+		for _, workers := range []int{0, 1, 2, 3, 8, 64} {
+			got, err := ParallelProduct(ctx, r[0], r[1], workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("ParallelProduct(%d, %d, %d workers) differs from Product", r[0], r[1], workers)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, c := range []struct {
+		lo, hi uint64
+		n      int
+	}{{1, 100, 7}, {1, 3, 8}, {10, 10, 4}, {1, 1000, 32}, {math.MaxUint64 - 20, math.MaxUint64, 8}, {0, math.MaxUint64, 4}} {
+		chunks := split(c.lo, c.hi, c.n)
+		if len(chunks) > c.n {
+			t.Errorf("split(%d, %d, %d) made %d chunks", c.lo, c.hi, c.n, len(chunks))
+		}
+		next := c.lo
+		for _, ch := range chunks {
+			if ch[0] != next || ch[1] < ch[0] {
+				t.Fatalf("split(%d, %d, %d) = %v", c.lo, c.hi, c.n, chunks)
+			}
+			next = ch[1] + 1
+		}
+		if next != c.hi+1 {
+			t.Errorf("split(%d, %d, %d) = %v doesn't cover the range", c.lo, c.hi, c.n, chunks)
+		}
+	}
+}
+
+func TestProductUpToMaxUint64(t *testing.T) {
+	ctx := context.Background()
+	for _, lo := range []uint64{math.MaxUint64, math.MaxUint64 - 1, math.MaxUint64 - 5, math.MaxUint64 - 100} {
+		want := big.NewInt(1)
+		for i := lo; ; i++ {
+			want.Mul(want, new(big.Int).SetUint64(i))
+			if i == math.MaxUint64 {
+				break
+			}
+		}
+		if got := Product(lo, math.MaxUint64); got.Cmp(want) != 0 {
+			t.Errorf("Product(%d, MaxUint64) = %v, want %v", lo, got, want)
+		}
+		got, err := ParallelProduct(ctx, lo, math.MaxUint64, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("ParallelProduct(%d, MaxUint64, 3 workers) differs from Product", lo)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParallelFactorial(ctx, 100000, 4); err != context.Canceled {
+		t.Errorf("ParallelFactorial with a cancelled context = %v", err)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("computes 50000!")
+	}
+	timing, err := Measure(context.Background(), 50000, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(timing)
+}
+
+func benchmarkFactorial(b *testing.B, n uint64, workers int) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		if workers == 0 {
+			Factorial(n)
+		} else if _, err := ParallelFactorial(ctx, n, workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFactorial compares the sequential computation (workers=0) with
+// the parallel one, e.g. go test -bench Factorial -benchtime 3x.
+func BenchmarkFactorial(b *testing.B) {
+	for _, n := range []uint64{1000, 20000, 100000} {
+		for _, workers := range []int{0, 1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				benchmarkFactorial(b, n, workers)
+			})
+		}
+	}
+}
+
+// BenchmarkNaive multiplies one number at a time, which the product tree
+// of Factorial beats by far for large n.
+func BenchmarkNaive(b *testing.B) {
+	for _, n := range []uint64{1000, 20000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				f := big.NewInt(1)
+				var x big.Int
+				for j := uint64(2); j <= n; j++ {
+					f.Mul(f, x.SetUint64(j))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Key codes understood by the Editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlU     = 21
+	keyBackspace = 127
+	keyCtrlH     = 8
+	keyEscape    = 27
+)
+
+// Editor reads lines of keystrokes with basic line editing: the arrow keys
+// move the cursor and walk through the history, backspace deletes, Ctrl-A
+// and Ctrl-E jump to the start and end of the line and Ctrl-U clears it.
+// It expects its input to come from a terminal in raw mode, see Terminal.
+type Editor struct {
+	// History holds the lines read so far, oldest first.
+	History []string
+	// HistoryFile, if set, gets every line appended to it.
+	HistoryFile string
+
+	in      *bufio.Reader
+	out     io.Writer
+	pending []byte // rest of the last line for Read
+}
+
+// NewEditor returns an editor reading keystrokes from in and echoing the
+// edited line to out.
+func NewEditor(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out}
+}
+
+// LoadHistory reads the history from path, one line per entry, and appends
+// new lines to it from now on. A missing file is an empty history.
+func (e *Editor) LoadHistory(path string) error {
+	e.HistoryFile = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, l := range strings.Split(string(data), "\n") {
+		if l != "" {
+			e.History = append(e.History, l)
+		}
+	}
+	return nil
+}
+
+// ReadLine reads a line. It returns io.EOF on Ctrl-D at an empty line and
+// ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine() (string, error) {
+	var line []rune
+	cur := 0               // cursor position in line
+	hist := len(e.History) // index in the history, len(History) is the new line
+	var draft []rune       // the new line while browsing the history
+
+	// redraw rewrites the line after the cursor moved from position from.
+	// It only moves relative to the cursor, so that a prompt printed before
+	// the line stays intact.
+	redraw := func(from int) {
+		var b bytes.Buffer
+		if from > 0 {
+			fmt.Fprintf(&b, "\x1b[%dD", from)
+		}
+		b.WriteString("\x1b[K")
+		b.WriteString(string(line))
+		if back := len(line) - cur; back > 0 {
+			fmt.Fprintf(&b, "\x1b[%dD", back)
+		}
+		e.out.Write(b.Bytes())
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(e.History) || i == hist {
+			return
+		}
+		if hist == len(e.History) {
+			draft = line
+		}
+		hist = i
+		from := cur
+		if i == len(e.History) {
+			line = draft
+		} else {
+			line = []rune(e.History[i])
+		}
+		cur = len(line)
+		redraw(from)
+	}
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err == io.EOF && len(line) > 0 {
+			return e.accept(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			return e.accept(line), nil
+		case keyCtrlC:
+			io.WriteString(e.out, "^C\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+		case keyCtrlA:
+			from := cur
+			cur = 0
+			redraw(from)
+		case keyCtrlE:
+			from := cur
+			cur = len(line)
+			redraw(from)
+		case keyCtrlU:
+			from := cur
+			line, cur = nil, 0
+			redraw(from)
+		case keyBackspace, keyCtrlH:
+			if cur > 0 {
+				line = append(line[:cur-1:cur-1], line[cur:]...)
+				cur--
+				redraw(cur + 1)
+			}
+		case keyEscape:
+			seq, err := e.escape()
+			if err != nil {
+				return "", err
+			}
+			switch seq {
+			case "[A": // up
+				recall(hist - 1)
+			case "[B": // down
+				recall(hist + 1)
+			case "[C": // right
+				if cur < len(line) {
+					cur++
+					io.WriteString(e.out, "\x1b[C")
+				}
+			case "[D": // left
+				if cur > 0 {
+					cur--
+					io.WriteString(e.out, "\x1b[D")
+				}
+			}
+		default:
+			if r < ' ' || r == utf8.RuneError {
+				continue
+			}
+			line = append(line[:cur], append([]rune{r}, line[cur:]...)...)
+			cur++
+			redraw(cur - 1)
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence such as "[A".
+func (e *Editor) escape() (string, error) {
+	b, err := e.in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if b != '[' && b != 'O' {
+		return string(b), nil
+	}
+	seq := []byte{'['}
+	for {
+		b, err := e.in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, b)
+		// a final byte ends the sequence
+		if b >= 0x40 && b <= 0x7e {
+			return string(seq), nil
+		}
+	}
+}
+
+func (e *Editor) accept(line []rune) string {
+	io.WriteString(e.out, "\n")
+	s := string(line)
+	if s == "" {
+		return s
+	}
+	if n := len(e.History); n == 0 || e.History[n-1] != s {
+		e.History = append(e.History, s)
+		if e.HistoryFile != "" {
+			os.MkdirAll(filepath.Dir(e.HistoryFile), 0700)
+			if f, err := os.OpenFile(e.HistoryFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err == nil {
+				fmt.Fprintln(f, s)
+				f.Close()
+			}
+		}
+	}
+	return s
+}
+
+// Read implements io.Reader, returning the edited lines followed by a
+// newline, so that an Editor can replace os.Stdin for a bufio.Scanner.
+func (e *Editor) Read(p []byte) (int, error) {
+	if len(e.pending) == 0 {
+		line, err := e.ReadLine()
+		if err != nil {
+			return 0, err
+		}
+		e.pending = []byte(line + "\n")
+	}
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
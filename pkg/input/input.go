@@ -0,0 +1,83 @@
+// Package input provides the sources lessons read user input from: scripted
+// answers for automated runs and a line editor with history for humans.
+//
+// Every source is an io.Reader, so lessons keep reading input the way they
+// teach it, with bufio.Scanner, and callers decide where it comes from.
+package input
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Lines returns a reader that yields each answer on its own line.
+func Lines(answers ...string) io.Reader {
+	if len(answers) == 0 {
+		return strings.NewReader("")
+	}
+	return strings.NewReader(strings.Join(answers, "\n") + "\n")
+}
+
+// File returns a reader for a scripted input file holding one answer per
+// line. The caller must close it.
+func File(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// Source describes where a command reads lesson input from. The zero value
+// reads the process's standard input unchanged.
+type Source struct {
+	// Answers are fed to the lesson one per line.
+	Answers []string
+	// File is a scripted input file, used when there are no Answers.
+	File string
+	// Interactive reads from a line editor with history when standard
+	// input is a terminal.
+	Interactive bool
+	// HistoryFile keeps the interactive history between runs.
+	HistoryFile string
+}
+
+// Open returns the reader described by s along with a function that releases
+// it, which must be called once the lesson is done reading.
+func (s Source) Open(stdin *os.File, out io.Writer) (io.Reader, func() error, error) {
+	nop := func() error { return nil }
+	switch {
+	case len(s.Answers) > 0:
+		return Lines(s.Answers...), nop, nil
+	case s.File != "":
+		f, err := File(s.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	case s.Interactive:
+		e, restore, err := Terminal(stdin, out)
+		if err == ErrNotTerminal {
+			return stdin, nop, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if s.HistoryFile != "" {
+			if err := e.LoadHistory(s.HistoryFile); err != nil {
+				restore()
+				return nil, nil, err
+			}
+		}
+		return e, restore, nil
+	}
+	return stdin, nop, nil
+}
+
+// DefaultHistoryFile returns the file interactive history is kept in, or ""
+// if there is no user config directory.
+func DefaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "little-engine", "history")
+}
@@ -0,0 +1,90 @@
+package input
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	sc := bufio.NewScanner(Lines("gopher", "ferris"))
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if strings.Join(got, ",") != "gopher,ferris" {
+		t.Errorf("scanned %q", got)
+	}
+}
+
+func TestEditor(t *testing.T) {
+	const (
+		up    = "\x1b[A"
+		down  = "\x1b[B"
+		left  = "\x1b[D"
+		right = "\x1b[C"
+		bs    = "\x7f"
+	)
+	keys := strings.Join([]string{
+		"gopherr" + bs + "\r",            // backspace
+		"opher" + "\x01" + "g\r",         // ctrl-a and insert at the start
+		"ab" + left + "X" + right + "\n", // arrows
+		up + up + "!\r",                  // second to last line from the history
+		"new" + up + down + "s\r",        // back to the draft line
+		"junk\x15ok\r",                   // ctrl-u clears the line
+		"日本" + bs + "\r",                 // multi byte runes
+	}, "")
+	e := NewEditor(strings.NewReader(keys), io.Discard)
+	want := []string{"gopher", "gopher", "aXb", "gopher!", "news", "ok", "日"}
+	for _, w := range want {
+		got, err := e.ReadLine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("ReadLine = %q, want %q", got, w)
+		}
+	}
+	if _, err := e.ReadLine(); err != io.EOF {
+		t.Errorf("ReadLine at the end of input returned %v, want io.EOF", err)
+	}
+	if got := strings.Join(e.History, ","); got != "gopher,aXb,gopher!,news,ok,日" {
+		t.Errorf("History = %s", got)
+	}
+}
+
+func TestEditorInterrupt(t *testing.T) {
+	e := NewEditor(strings.NewReader("abc\x03"), io.Discard)
+	if _, err := e.ReadLine(); err != ErrInterrupted {
+		t.Errorf("ReadLine after Ctrl-C returned %v, want ErrInterrupted", err)
+	}
+}
+
+func TestEditorReaderAndHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEditor(strings.NewReader("\x1b[A\rnow\r"), io.Discard)
+	if err := e.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(e)
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if strings.Join(got, ",") != "earlier,now" {
+		t.Errorf("scanned %q", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "earlier\nnow\n" {
+		t.Errorf("history file = %q", data)
+	}
+}
@@ -0,0 +1,65 @@
+package input
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty returns the master and slave ends of a new pseudo terminal.
+func openPty(t *testing.T) (master, slave *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo terminals:", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	var unlock, n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Fatal(errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Fatal(errno)
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { slave.Close() })
+	return master, slave
+}
+
+func TestTerminalInterrupt(t *testing.T) {
+	master, slave := openPty(t)
+	e, restore, err := Terminal(slave, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw syscall.Termios
+	if err := ioctl(int(slave.Fd()), ioctlGetTermios, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Lflag&syscall.ISIG != 0 {
+		t.Fatal("raw mode leaves ISIG on, Ctrl-C would send SIGINT")
+	}
+	// without ISIG the terminal passes Ctrl-C on as a byte
+	if _, err := master.Write([]byte("abc\x03")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.ReadLine(); err != ErrInterrupted {
+		t.Errorf("ReadLine after Ctrl-C returned %v, want ErrInterrupted", err)
+	}
+
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	var cooked syscall.Termios
+	if err := ioctl(int(slave.Fd()), ioctlGetTermios, &cooked); err != nil {
+		t.Fatal(err)
+	}
+	if cooked.Lflag&syscall.ISIG == 0 {
+		t.Error("restore left ISIG off")
+	}
+}
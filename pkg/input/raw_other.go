@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package input
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, ErrNotTerminal
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package input
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw turns off line buffering, echo and signal keys of the terminal fd,
+// leaving output processing alone. Ctrl-C then reaches the Editor as a key
+// instead of killing the process with the terminal still in raw mode.
+func makeRaw(fd int) (func() error, error) {
+	var old syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, &old); err != nil {
+		return nil, ErrNotTerminal
+	}
+	raw := old
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return ioctl(fd, ioctlSetTermios, &old)
+	}, nil
+}
+
+func ioctl(fd int, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
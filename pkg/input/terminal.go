@@ -0,0 +1,22 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNotTerminal is returned by Terminal when the file isn't a terminal or
+// line editing isn't supported on the platform.
+var ErrNotTerminal = errors.New("not a terminal")
+
+// Terminal switches the terminal f to raw mode and returns an Editor reading
+// from it and echoing to out, along with a function that restores the
+// terminal. Callers should fall back to reading f directly on ErrNotTerminal.
+func Terminal(f *os.File, out io.Writer) (*Editor, func() error, error) {
+	restore, err := makeRaw(int(f.Fd()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewEditor(f, out), restore, nil
+}
@@ -0,0 +1,449 @@
+// Package interleave runs small concurrent programs under a cooperative
+// scheduler and explores their interleavings systematically, so that a race
+// condition shows up every time rather than once in a while.
+//
+// A program starts its threads with Run.Go and shares data through Var and
+// Mutex, whose operations are the points at which the scheduler may switch
+// threads. Everything a thread does between two of them runs without
+// interruption. Explore runs the program once for every schedule up to a
+// bound and returns the first one for which its check fails, which Replay
+// runs again step by step.
+//
+//	prog := func(r *interleave.Run) func() error {
+//		n := interleave.NewVar(r, "n", 0)
+//		for _, name := range []string{"a", "b"} {
+//			r.Go(name, func(t *interleave.Thread) {
+//				n.Store(t, n.Load(t)+1)
+//			})
+//		}
+//		return func() error {
+//			if n.Value() != 2 {
+//				return fmt.Errorf("n = %d", n.Value())
+//			}
+//			return nil
+//		}
+//	}
+//	res := interleave.Explore(prog, interleave.Options{})
+//	fmt.Println(res.Failure) // a schedule and trace ending with n = 1
+package interleave
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Program sets up a concurrent program: it creates its shared variables,
+// starts its threads with r.Go and returns a check of the final state, which
+// is called once every thread is done.
+type Program func(r *Run) (check func() error)
+
+// Options bound the exploration.
+type Options struct {
+	// MaxPreemptions is the number of times a schedule may switch away
+	// from a thread that could have continued. Most concurrency bugs need
+	// only one or two. Zero means 2, a negative value means no bound.
+	MaxPreemptions int
+	// MaxSchedules stops the exploration after this many schedules. Zero
+	// means 100000.
+	MaxSchedules int
+}
+
+// Schedule is the thread chosen at each step of a run, by index in the order
+// the threads were started.
+type Schedule []int
+
+// String returns the schedule as comma separated thread indexes, the format
+// ParseSchedule reads.
+func (s Schedule) String() string {
+	parts := make([]string, len(s))
+	for i, t := range s {
+		parts[i] = strconv.Itoa(t)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSchedule parses a schedule printed by Schedule.String.
+func ParseSchedule(s string) (Schedule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sched Schedule
+	for _, part := range strings.Split(s, ",") {
+		t, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || t < 0 {
+			return nil, fmt.Errorf("interleave: bad schedule %q", s)
+		}
+		sched = append(sched, t)
+	}
+	return sched, nil
+}
+
+// Step is an operation a thread performed.
+type Step struct {
+	Thread int
+	Name   string // the name of the thread
+	Op     string // e.g. "load n = 42"
+}
+
+func (s Step) String() string {
+	return fmt.Sprintf("%s: %s", s.Name, s.Op)
+}
+
+// Failure is a schedule for which the program went wrong.
+type Failure struct {
+	Schedule Schedule
+	Trace    []Step
+	// Err is the error of the check, a panic of a thread or ErrDeadlock.
+	Err error
+}
+
+func (f *Failure) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schedule %s:\n", f.Schedule)
+	for _, s := range f.Trace {
+		fmt.Fprintf(&b, "  %s\n", s)
+	}
+	fmt.Fprintf(&b, "%v", f.Err)
+	return b.String()
+}
+
+// Result is the outcome of Explore.
+type Result struct {
+	// Schedules is the number of schedules that were run.
+	Schedules int
+	// Complete reports whether every schedule within MaxPreemptions was
+	// run, rather than the exploration stopping at a failure or at
+	// MaxSchedules.
+	Complete bool
+	// Failure is the first failing schedule, nil if none failed.
+	Failure *Failure
+}
+
+// ErrDeadlock is the error of a schedule in which every thread that isn't
+// done waits for a Mutex.
+var ErrDeadlock = errors.New("interleave: deadlock, every thread is waiting for a mutex")
+
+// Explore runs prog under every schedule with at most opts.MaxPreemptions
+// preemptions, in depth-first order starting with the one that runs each
+// thread to the end before the next, until a schedule fails or opts.
+// MaxSchedules were run.
+func Explore(prog Program, opts Options) Result {
+	if opts.MaxPreemptions == 0 {
+		opts.MaxPreemptions = 2
+	}
+	if opts.MaxSchedules == 0 {
+		opts.MaxSchedules = 100000
+	}
+	var res Result
+	var prefix Schedule
+	for res.Schedules < opts.MaxSchedules {
+		r := execute(prog, prefix, false)
+		res.Schedules++
+		if r.err != nil {
+			res.Failure = &Failure{Schedule: r.schedule(), Trace: r.trace, Err: r.err}
+			return res
+		}
+		var ok bool
+		if prefix, ok = next(r.decisions, opts.MaxPreemptions); !ok {
+			res.Complete = true
+			return res
+		}
+	}
+	return res
+}
+
+// Replay runs prog under sched and returns the steps it took. The error is
+// that of the check, a panic, ErrDeadlock, or an error for a schedule that
+// picks a thread that can't run. Steps after the end of sched keep running
+// the current thread.
+func Replay(prog Program, sched Schedule) ([]Step, error) {
+	r := execute(prog, sched, true)
+	return r.trace, r.err
+}
+
+// decision is a point of a run at which the scheduler picked a thread.
+type decision struct {
+	// options are the threads that could run, the one that ran last first
+	// if it could continue.
+	options []int
+	chosen  int // index into options
+	// preemptive is whether options[0] is the thread that ran last, so
+	// that choosing any other is a preemption.
+	preemptive bool
+}
+
+// next returns the schedule prefix of the run that comes after the one that
+// made decisions, or false if there is none within maxPreemptions.
+func next(decisions []decision, maxPreemptions int) (Schedule, bool) {
+	for i := len(decisions) - 1; i >= 0; i-- {
+		d := decisions[i]
+		if d.chosen+1 >= len(d.options) {
+			continue
+		}
+		preemptions := 0
+		for _, prev := range decisions[:i] {
+			if prev.preemptive && prev.chosen > 0 {
+				preemptions++
+			}
+		}
+		if d.preemptive {
+			preemptions++
+		}
+		if maxPreemptions >= 0 && preemptions > maxPreemptions {
+			continue
+		}
+		prefix := make(Schedule, 0, i+1)
+		for _, prev := range decisions[:i] {
+			prefix = append(prefix, prev.options[prev.chosen])
+		}
+		return append(prefix, d.options[d.chosen+1]), true
+	}
+	return nil, false
+}
+
+// Run is a single run of a program. Its threads run one at a time, each
+// until it reaches an operation on a Var or a Mutex, where it hands control
+// back to the scheduler.
+type Run struct {
+	threads   []*Thread
+	yielded   chan *Thread // a thread reached an operation or ended
+	abort     chan struct{}
+	started   bool
+	trace     []Step
+	decisions []decision
+	err       error
+}
+
+// Thread is a thread of a program.
+type Thread struct {
+	r    *Run
+	id   int
+	name string
+	wake chan struct{}
+	// ready reports whether the pending operation can run now, nil if it
+	// always can.
+	ready   func() bool
+	done    bool
+	aborted bool  // the run ended before the thread did
+	err     error // a panic of the thread
+}
+
+// Go starts a thread running f. Threads can only be started by the Program
+// itself, not by other threads.
+func (r *Run) Go(name string, f func(t *Thread)) {
+	if r.started {
+		panic("interleave: Go called from a thread")
+	}
+	t := &Thread{r: r, id: len(r.threads), name: name, wake: make(chan struct{})}
+	r.threads = append(r.threads, t)
+	go func() {
+		defer func() {
+			if t.aborted {
+				// a deferred call of f may panic while the thread unwinds
+				recover()
+				return
+			}
+			if v := recover(); v != nil {
+				t.err = fmt.Errorf("thread %s panicked: %v", t.name, v)
+			}
+			t.done = true
+			r.yielded <- t
+		}()
+		t.wait()
+		f(t)
+	}()
+}
+
+// wait blocks until the scheduler runs t, or ends the goroutine if the run
+// was aborted.
+func (t *Thread) wait() {
+	select {
+	case <-t.wake:
+	case <-t.r.abort:
+		t.aborted = true
+		runtime.Goexit()
+	}
+}
+
+// yield hands control back to the scheduler before an operation that may
+// run once ready returns true, and returns when the scheduler picks t. In an
+// aborted run, like by the deferred Unlock calls of a thread that wait ended,
+// yield ends the goroutine at once, since there's no scheduler to hand
+// control to anymore.
+func (t *Thread) yield(ready func() bool) {
+	if t.aborted {
+		runtime.Goexit()
+	}
+	t.ready = ready
+	select {
+	case t.r.yielded <- t:
+	case <-t.r.abort:
+		t.aborted = true
+		runtime.Goexit()
+	}
+	t.wait()
+	t.ready = nil
+}
+
+// record adds an operation of t to the trace.
+func (t *Thread) record(format string, args ...any) {
+	t.r.trace = append(t.r.trace, Step{Thread: t.id, Name: t.name, Op: fmt.Sprintf(format, args...)})
+}
+
+func (r *Run) schedule() Schedule {
+	s := make(Schedule, len(r.decisions))
+	for i, d := range r.decisions {
+		s[i] = d.options[d.chosen]
+	}
+	return s
+}
+
+// execute runs prog following prefix and then the thread that ran last for
+// as long as it can. If strict, a prefix that picks a thread that can't run
+// is an error; otherwise the run continues as if the prefix had ended.
+func execute(prog Program, prefix Schedule, strict bool) *Run {
+	r := &Run{yielded: make(chan *Thread), abort: make(chan struct{})}
+	defer close(r.abort)
+	check := prog(r)
+	r.started = true
+	// run every thread to its first operation
+	for _, t := range r.threads {
+		t.wake <- struct{}{}
+		<-r.yielded
+		if t.err != nil {
+			r.err = t.err
+			return r
+		}
+	}
+	last := -1
+	for step := 0; ; step++ {
+		var options []int
+		preemptive := false
+		if last >= 0 && r.threads[last].runnable() {
+			options = append(options, last)
+			preemptive = true
+		}
+		for _, t := range r.threads {
+			if t.id != last && t.runnable() {
+				options = append(options, t.id)
+			}
+		}
+		if len(options) == 0 {
+			for _, t := range r.threads {
+				if !t.done {
+					r.err = ErrDeadlock
+					return r
+				}
+			}
+			break
+		}
+		chosen := 0
+		if step < len(prefix) {
+			i := index(options, prefix[step])
+			switch {
+			case i >= 0:
+				chosen = i
+			case strict:
+				r.err = fmt.Errorf("interleave: step %d of the schedule picks thread %d, which can't run", step, prefix[step])
+				return r
+			}
+		}
+		r.decisions = append(r.decisions, decision{options: options, chosen: chosen, preemptive: preemptive})
+		t := r.threads[options[chosen]]
+		t.wake <- struct{}{}
+		<-r.yielded
+		if t.err != nil {
+			r.err = t.err
+			return r
+		}
+		last = t.id
+	}
+	r.err = check()
+	return r
+}
+
+func (t *Thread) runnable() bool {
+	return !t.done && (t.ready == nil || t.ready())
+}
+
+func index(s []int, v int) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// Var is a variable shared by the threads of a program. Every Load and Store
+// is a point at which another thread may run.
+type Var[T any] struct {
+	name string
+	v    T
+}
+
+// NewVar returns a variable of the program called name holding v.
+func NewVar[T any](r *Run, name string, v T) *Var[T] {
+	return &Var[T]{name: name, v: v}
+}
+
+// Load returns the value of the variable.
+func (v *Var[T]) Load(t *Thread) T {
+	t.yield(nil)
+	t.record("load %s = %v", v.name, v.v)
+	return v.v
+}
+
+// Store sets the variable to x.
+func (v *Var[T]) Store(t *Thread, x T) {
+	t.yield(nil)
+	t.record("store %s = %v", v.name, x)
+	v.v = x
+}
+
+// Add adds delta to the variable in a single step, like the functions of
+// sync/atomic, and returns the new value.
+func Add[T ~int | ~int64 | ~int32](t *Thread, v *Var[T], delta T) T {
+	t.yield(nil)
+	v.v += delta
+	t.record("add %v to %s = %v", delta, v.name, v.v)
+	return v.v
+}
+
+// Value returns the value of the variable. It's meant for the check of a
+// program, which runs after its threads are done.
+func (v *Var[T]) Value() T {
+	return v.v
+}
+
+// Mutex is a mutex of a program. A thread waiting in Lock doesn't run until
+// the mutex is unlocked.
+type Mutex struct {
+	name   string
+	holder *Thread
+}
+
+// NewMutex returns an unlocked mutex of the program called name.
+func NewMutex(r *Run, name string) *Mutex {
+	return &Mutex{name: name}
+}
+
+// Lock waits until the mutex is unlocked and locks it.
+func (m *Mutex) Lock(t *Thread) {
+	t.yield(func() bool { return m.holder == nil })
+	m.holder = t
+	t.record("lock %s", m.name)
+}
+
+// Unlock unlocks the mutex. It panics if t doesn't hold it.
+func (m *Mutex) Unlock(t *Thread) {
+	if m.holder != t {
+		panic("interleave: unlock of " + m.name + " by a thread that doesn't hold it")
+	}
+	t.yield(nil)
+	m.holder = nil
+	t.record("unlock %s", m.name)
+}
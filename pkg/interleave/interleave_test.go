@@ -0,0 +1,241 @@
+package interleave
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+// counter is the data race of the concurrency lesson: incs threads run
+// n++ and as many run n--, as a load and a store each.
+func counter(incs int) Program {
+	return func(r *Run) func() error {
+		n := NewVar(r, "n", 0)
+		for i := 0; i < incs; i++ {
+			r.Go(fmt.Sprintf("inc%d", i), func(t *Thread) { n.Store(t, n.Load(t)+1) })
+			r.Go(fmt.Sprintf("dec%d", i), func(t *Thread) { n.Store(t, n.Load(t)-1) })
+		}
+		return func() error {
+			if v := n.Value(); v != 0 {
+				return fmt.Errorf("n = %d, want 0", v)
+			}
+			return nil
+		}
+	}
+}
+
+func TestExploreFindsRace(t *testing.T) {
+	leakcheck.Check(t)
+	res := Explore(counter(1), Options{})
+	if res.Failure == nil {
+		t.Fatalf("no failure in %d schedules", res.Schedules)
+	}
+	f := res.Failure
+	if got, want := f.Schedule.String(), "0,1,1,0"; got != want {
+		t.Errorf("failing schedule %s, want %s", got, want)
+	}
+	var ops []string
+	for _, s := range f.Trace {
+		ops = append(ops, s.String())
+	}
+	want := "inc0: load n = 0|dec0: load n = 0|dec0: store n = -1|inc0: store n = 1"
+	if got := strings.Join(ops, "|"); got != want {
+		t.Errorf("trace\n%s\nwant\n%s", got, want)
+	}
+	if f.Err.Error() != "n = 1, want 0" {
+		t.Errorf("error %v", f.Err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	leakcheck.Check(t)
+	res := Explore(counter(2), Options{MaxPreemptions: 1})
+	if res.Failure == nil {
+		t.Fatal("no failure")
+	}
+	sched, err := ParseSchedule(res.Failure.Schedule.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same schedule fails the same way every time
+	for i := 0; i < 3; i++ {
+		trace, err := Replay(counter(2), sched)
+		if err == nil || err.Error() != res.Failure.Err.Error() {
+			t.Fatalf("replay %d returned %v, want %v", i, err, res.Failure.Err)
+		}
+		if len(trace) != len(res.Failure.Trace) {
+			t.Fatalf("replay %d took %d steps, want %d", i, len(trace), len(res.Failure.Trace))
+		}
+	}
+	if _, err := Replay(counter(1), Schedule{5}); err == nil {
+		t.Error("replaying a schedule with a thread that doesn't exist succeeded")
+	}
+}
+
+func TestMutexAndAddAreCorrect(t *testing.T) {
+	leakcheck.Check(t)
+	progs := map[string]Program{
+		"mutex": func(r *Run) func() error {
+			n := NewVar(r, "n", 0)
+			mu := NewMutex(r, "mu")
+			for _, d := range []int{1, -1, 1, -1} {
+				r.Go("t", func(t *Thread) {
+					mu.Lock(t)
+					n.Store(t, n.Load(t)+d)
+					mu.Unlock(t)
+				})
+			}
+			return func() error {
+				if n.Value() != 0 {
+					return fmt.Errorf("n = %d", n.Value())
+				}
+				return nil
+			}
+		},
+		"add": func(r *Run) func() error {
+			n := NewVar(r, "n", 0)
+			for _, d := range []int{1, -1, 1, -1} {
+				r.Go("t", func(t *Thread) { Add(t, n, d) })
+			}
+			return func() error {
+				if n.Value() != 0 {
+					return fmt.Errorf("n = %d", n.Value())
+				}
+				return nil
+			}
+		},
+	}
+	for name, prog := range progs {
+		res := Explore(prog, Options{MaxPreemptions: -1})
+		if res.Failure != nil {
+			t.Errorf("%s: %v", name, res.Failure)
+		}
+		if !res.Complete || res.Schedules < 2 {
+			t.Errorf("%s: explored %d schedules, complete %v", name, res.Schedules, res.Complete)
+		}
+	}
+}
+
+func TestPreemptionBound(t *testing.T) {
+	// with no preemptions every thread runs to the end, which is correct
+	res := Explore(counter(2), Options{MaxPreemptions: -1, MaxSchedules: 1})
+	if res.Failure != nil || res.Complete {
+		t.Errorf("first schedule: %+v", res)
+	}
+	// every interleaving of two threads of two steps each: 4 choose 2
+	res = Explore(func(r *Run) func() error {
+		n := NewVar(r, "n", 0)
+		for i := 0; i < 2; i++ {
+			r.Go("t", func(t *Thread) { n.Load(t); n.Load(t) })
+		}
+		return func() error { return nil }
+	}, Options{MaxPreemptions: -1})
+	if res.Schedules != 6 || !res.Complete {
+		t.Errorf("explored %d schedules, want 6", res.Schedules)
+	}
+}
+
+func TestDeadlock(t *testing.T) {
+	leakcheck.Check(t)
+	res := Explore(func(r *Run) func() error {
+		a, b := NewMutex(r, "a"), NewMutex(r, "b")
+		r.Go("ab", func(t *Thread) { a.Lock(t); b.Lock(t); b.Unlock(t); a.Unlock(t) })
+		r.Go("ba", func(t *Thread) { b.Lock(t); a.Lock(t); a.Unlock(t); b.Unlock(t) })
+		return func() error { return nil }
+	}, Options{})
+	if res.Failure == nil || !errors.Is(res.Failure.Err, ErrDeadlock) {
+		t.Fatalf("no deadlock found: %+v", res)
+	}
+}
+
+func TestDeadlockWithDeferredUnlock(t *testing.T) {
+	leakcheck.Check(t)
+	prog := func(r *Run) func() error {
+		a, b := NewMutex(r, "a"), NewMutex(r, "b")
+		r.Go("ab", func(t *Thread) {
+			a.Lock(t)
+			defer a.Unlock(t)
+			b.Lock(t)
+			defer b.Unlock(t)
+		})
+		r.Go("ba", func(t *Thread) {
+			b.Lock(t)
+			defer b.Unlock(t)
+			a.Lock(t)
+			defer a.Unlock(t)
+		})
+		return func() error { return nil }
+	}
+	// the deferred Unlock calls run when the deadlocked threads are ended
+	for i := 0; i < 10; i++ {
+		res := Explore(prog, Options{})
+		if res.Failure == nil || !errors.Is(res.Failure.Err, ErrDeadlock) {
+			t.Fatalf("no deadlock found: %+v", res)
+		}
+	}
+}
+
+func TestPanicInDeferDuringAbort(t *testing.T) {
+	leakcheck.Check(t)
+	res := Explore(func(r *Run) func() error {
+		a, b := NewMutex(r, "a"), NewMutex(r, "b")
+		r.Go("ab", func(t *Thread) {
+			locked := false
+			defer func() {
+				if !locked {
+					panic("ended without the locks")
+				}
+			}()
+			a.Lock(t)
+			b.Lock(t)
+			locked = true
+		})
+		r.Go("ba", func(t *Thread) { b.Lock(t); a.Lock(t) })
+		return func() error { return nil }
+	}, Options{})
+	if res.Failure == nil || !errors.Is(res.Failure.Err, ErrDeadlock) {
+		t.Fatalf("no deadlock found: %+v", res)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	leakcheck.Check(t)
+	res := Explore(func(r *Run) func() error {
+		mu := NewMutex(r, "mu")
+		r.Go("bad", func(t *Thread) { mu.Unlock(t) })
+		r.Go("other", func(t *Thread) { mu.Lock(t) })
+		return func() error { return nil }
+	}, Options{})
+	if res.Failure == nil || !strings.Contains(res.Failure.Err.Error(), "thread bad panicked") {
+		t.Fatalf("panic not reported: %+v", res.Failure)
+	}
+}
+
+func ExampleExplore() {
+	prog := func(r *Run) func() error {
+		n := NewVar(r, "n", 0)
+		for _, name := range []string{"a", "b"} {
+			r.Go(name, func(t *Thread) {
+				n.Store(t, n.Load(t)+1)
+			})
+		}
+		return func() error {
+			if n.Value() != 2 {
+				return fmt.Errorf("n = %d, want 2", n.Value())
+			}
+			return nil
+		}
+	}
+	res := Explore(prog, Options{})
+	fmt.Println(res.Failure)
+	// Output:
+	// schedule 0,1,1,0:
+	//   a: load n = 0
+	//   b: load n = 0
+	//   b: store n = 1
+	//   a: store n = 1
+	// n = 1, want 2
+}
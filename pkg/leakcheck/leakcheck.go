@@ -0,0 +1,201 @@
+// Package leakcheck reports goroutines that a test started and didn't stop.
+//
+//	func TestWorkers(t *testing.T) {
+//		leakcheck.Check(t)
+//		...
+//	}
+//
+// Check records the goroutines running when it is called and, once the test
+// is done, fails it if new goroutines are still running, printing their
+// stacks and the line that started them.
+package leakcheck
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Goroutine is a goroutine in a stack dump.
+type Goroutine struct {
+	ID    int
+	State string // e.g. "chan send" or "select, 2 minutes"
+	// Top is the function the goroutine is executing.
+	Top string
+	// CreatedBy is the function and location that started the goroutine,
+	// e.g. "main.main in goroutine 1 at /src/main.go:12". It is empty for
+	// the main goroutine.
+	CreatedBy string
+	// Stack is the goroutine's full stack trace as printed by the runtime.
+	Stack string
+}
+
+func (g Goroutine) String() string {
+	return g.Stack
+}
+
+// Snapshot is the set of goroutines running at a point in time.
+type Snapshot map[int]Goroutine
+
+// Take returns the goroutines running now, except the calling one.
+func Take() Snapshot {
+	s := Snapshot{}
+	for _, g := range parse(dump())[1:] {
+		s[g.ID] = g
+	}
+	return s
+}
+
+func dump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parse splits a dump of runtime.Stack into goroutines. The calling goroutine
+// comes first.
+func parse(dump []byte) []Goroutine {
+	var gs []Goroutine
+	for _, block := range bytes.Split(bytes.TrimSpace(dump), []byte("\n\n")) {
+		lines := strings.Split(string(block), "\n")
+		// goroutine 7 [chan receive]:
+		id, state, ok := strings.Cut(strings.TrimPrefix(lines[0], "goroutine "), " ")
+		if !ok {
+			continue
+		}
+		g := Goroutine{
+			State: strings.TrimSuffix(strings.TrimPrefix(state, "["), "]:"),
+			Stack: string(block),
+		}
+		g.ID, _ = strconv.Atoi(id)
+		if len(lines) > 1 {
+			g.Top = funcName(lines[1])
+		}
+		for i, l := range lines {
+			if strings.HasPrefix(l, "created by ") && i+1 < len(lines) {
+				at, _, _ := strings.Cut(strings.TrimSpace(lines[i+1]), " +0x")
+				g.CreatedBy = strings.TrimPrefix(l, "created by ") + " at " + at
+			}
+		}
+		gs = append(gs, g)
+	}
+	return gs
+}
+
+// funcName returns the function of a stack frame line such as
+// "main.worker(0xc000012345, 0x2)".
+func funcName(frame string) string {
+	if i := strings.LastIndex(frame, "("); i > 0 {
+		return frame[:i]
+	}
+	return frame
+}
+
+// ignored are functions at the top of goroutines that the runtime, the
+// testing package and the standard library start and keep running.
+var ignored = []string{
+	"testing.(*T).Run",
+	"testing.(*T).Parallel",
+	"testing.(*F).Fuzz",
+	"testing.runTests",
+	"testing.runFuzzTests",
+	"testing.tRunner.func1",
+	"testing.(*M).startAlarm",
+	"runtime.ensureSigM",
+	"runtime.ReadTrace",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+}
+
+// Option configures Check and Leaked.
+type Option func(*config)
+
+type config struct {
+	ignore  []string
+	timeout time.Duration
+}
+
+// IgnoreTopFunction ignores goroutines executing the function fn, given by
+// its full name, e.g. "net/http.(*persistConn).readLoop".
+func IgnoreTopFunction(fn string) Option {
+	return func(c *config) { c.ignore = append(c.ignore, fn) }
+}
+
+// Timeout sets how long goroutines get to exit before they are reported,
+// one second by default.
+func Timeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// Leaked returns the goroutines running now that aren't in before. Because a
+// goroutine may still be on its way out after signalling that it's done,
+// Leaked retries until none are left or the timeout expires.
+func Leaked(before Snapshot, opts ...Option) []Goroutine {
+	c := config{ignore: ignored, timeout: time.Second}
+	for _, o := range opts {
+		o(&c)
+	}
+	deadline := time.Now().Add(c.timeout)
+	for wait := time.Millisecond; ; wait *= 2 {
+		var leaked []Goroutine
+		for _, g := range parse(dump())[1:] {
+			if _, ok := before[g.ID]; !ok && !c.ignores(g) {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(min(wait, time.Until(deadline), 100*time.Millisecond))
+	}
+}
+
+func (c config) ignores(g Goroutine) bool {
+	for _, fn := range c.ignore {
+		if g.Top == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// Check fails t if goroutines started after Check was called are still
+// running when the test and its cleanups are done. Tests that call Check
+// must not be parallel, since the goroutines of other tests would count as
+// leaked.
+func Check(t testing.TB, opts ...Option) {
+	t.Helper()
+	before := Take()
+	t.Cleanup(func() {
+		if t.Failed() {
+			// the leak is most likely a consequence of the failure
+			return
+		}
+		if leaked := Leaked(before, opts...); len(leaked) > 0 {
+			t.Error(Report(leaked))
+		}
+	})
+}
+
+// Report describes leaked goroutines, starting with where they were created.
+func Report(leaked []Goroutine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d goroutine(s) leaked:", len(leaked))
+	for _, g := range leaked {
+		fmt.Fprintf(&b, "\n\ngoroutine %d [%s] in %s", g.ID, g.State, g.Top)
+		if g.CreatedBy != "" {
+			fmt.Fprintf(&b, "\ncreated by %s", g.CreatedBy)
+		}
+		fmt.Fprintf(&b, "\n%s", g.Stack)
+	}
+	return b.String()
+}
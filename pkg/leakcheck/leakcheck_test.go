@@ -0,0 +1,95 @@
+package leakcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeaked(t *testing.T) {
+	before := Take()
+	block := make(chan struct{})
+	go blocked(block)
+
+	leaked := Leaked(before, Timeout(20*time.Millisecond))
+	if len(leaked) != 1 {
+		t.Fatalf("found %d leaked goroutines, want 1:\n%s", len(leaked), Report(leaked))
+	}
+	g := leaked[0]
+	if g.State != "chan receive" {
+		t.Errorf("State = %q, want chan receive", g.State)
+	}
+	if !strings.HasSuffix(g.Top, "leakcheck.blocked") {
+		t.Errorf("Top = %q, want leakcheck.blocked", g.Top)
+	}
+	if !strings.Contains(g.CreatedBy, "leakcheck.TestLeaked") || !strings.Contains(g.CreatedBy, "leakcheck_test.go:") {
+		t.Errorf("CreatedBy = %q, want the line in TestLeaked", g.CreatedBy)
+	}
+	report := Report(leaked)
+	for _, want := range []string{"1 goroutine(s) leaked", "created by ", "leakcheck_test.go:"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report doesn't mention %q:\n%s", want, report)
+		}
+	}
+
+	// goroutines that are on their way out get time to exit
+	close(block)
+	if leaked := Leaked(before); len(leaked) > 0 {
+		t.Errorf("goroutine didn't exit:\n%s", Report(leaked))
+	}
+}
+
+func blocked(c chan struct{}) {
+	<-c
+}
+
+func TestIgnore(t *testing.T) {
+	before := Take()
+	block := make(chan struct{})
+	defer close(block)
+	go blocked(block)
+	if leaked := Leaked(before, IgnoreTopFunction("github.com/ssukruth/little-engine-go/pkg/leakcheck.blocked")); len(leaked) > 0 {
+		t.Errorf("ignored goroutine was reported:\n%s", Report(leaked))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ft := &fakeTB{TB: t}
+	Check(ft, Timeout(20*time.Millisecond))
+	block := make(chan struct{})
+	go blocked(block)
+	ft.cleanup()
+	close(block)
+	if !strings.Contains(ft.errors, "leaked") {
+		t.Errorf("Check didn't report the leak, got %q", ft.errors)
+	}
+
+	ft = &fakeTB{TB: t}
+	Check(ft)
+	done := make(chan struct{})
+	go func() { close(done) }()
+	<-done
+	ft.cleanup()
+	if ft.errors != "" {
+		t.Errorf("Check reported %q", ft.errors)
+	}
+}
+
+// fakeTB records errors and runs cleanups on demand.
+type fakeTB struct {
+	testing.TB
+	cleanups []func()
+	errors   string
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Failed() bool      { return f.errors != "" }
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Error(args ...any) { f.errors += fmt.Sprint(args...) }
+
+func (f *fakeTB) cleanup() {
+	for _, fn := range f.cleanups {
+		fn()
+	}
+}
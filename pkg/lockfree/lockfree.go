@@ -0,0 +1,11 @@
+// Package lockfree implements a Stack and a Queue that many goroutines can
+// use at the same time without a mutex. Every change is made with a
+// CompareAndSwap loop on an atomic pointer: a goroutine prepares the change,
+// swaps it in if nobody changed the structure in the meantime, and retries
+// otherwise.
+//
+// A goroutine is never blocked by another one holding a lock, but under
+// heavy contention the retries cost as much as waiting for a mutex. Measure
+// before picking them over a mutex-protected slice or a channel; the
+// benchmarks of the package compare the three.
+package lockfree
@@ -0,0 +1,221 @@
+package lockfree
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestStack(t *testing.T) {
+	var s Stack[int]
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop of an empty stack returned ok")
+	}
+	for i := 1; i <= 3; i++ {
+		s.Push(i)
+	}
+	var got []int
+	for v, ok := s.Pop(); ok; v, ok = s.Pop() {
+		got = append(got, v)
+	}
+	if want := []int{3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("popped %v, want %v", got, want)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[int]()
+	if _, ok := q.Dequeue(); ok {
+		t.Fatal("Dequeue of an empty queue returned ok")
+	}
+	for i := 1; i <= 3; i++ {
+		q.Enqueue(i)
+	}
+	var got []int
+	for v, ok := q.Dequeue(); ok; v, ok = q.Dequeue() {
+		got = append(got, v)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("dequeued %v, want %v", got, want)
+	}
+}
+
+const (
+	producers = 8
+	perWorker = 2000
+)
+
+// hammer runs producers that put perWorker values each and as many
+// consumers that take values until they have taken all of them, and
+// returns what every consumer took in order.
+func hammer(t *testing.T, put func(int), take func() (int, bool)) [][]int {
+	leakcheck.Check(t)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		taken int
+		got   = make([][]int, producers)
+	)
+	for p := 0; p < producers; p++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				put(p*perWorker + i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				done := taken == producers*perWorker
+				mu.Unlock()
+				if done {
+					return
+				}
+				if v, ok := take(); ok {
+					got[p] = append(got[p], v)
+					mu.Lock()
+					taken++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return got
+}
+
+func TestStackConcurrent(t *testing.T) {
+	var s Stack[int]
+	got := hammer(t, s.Push, s.Pop)
+	checkAllTakenOnce(t, got)
+}
+
+func TestQueueConcurrent(t *testing.T) {
+	q := NewQueue[int]()
+	got := hammer(t, q.Enqueue, q.Dequeue)
+	checkAllTakenOnce(t, got)
+	// a consumer sees the values of every producer in the order they were
+	// enqueued
+	for c, vs := range got {
+		last := make(map[int]int)
+		for _, v := range vs {
+			p := v / perWorker
+			if prev, ok := last[p]; ok && v < prev {
+				t.Fatalf("consumer %d took %d after %d", c, v, prev)
+			}
+			last[p] = v
+		}
+	}
+}
+
+func checkAllTakenOnce(t *testing.T, got [][]int) {
+	t.Helper()
+	seen := make([]bool, producers*perWorker)
+	for _, vs := range got {
+		for _, v := range vs {
+			if seen[v] {
+				t.Fatalf("%d was taken twice", v)
+			}
+			seen[v] = true
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("%d was never taken", v)
+		}
+	}
+}
+
+// mutexStack and chanQueue are what Stack and Queue are benchmarked
+// against.
+type mutexStack struct {
+	mu     sync.Mutex
+	values []int
+}
+
+func (s *mutexStack) Push(v int) {
+	s.mu.Lock()
+	s.values = append(s.values, v)
+	s.mu.Unlock()
+}
+
+func (s *mutexStack) Pop() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.values) == 0 {
+		return 0, false
+	}
+	v := s.values[len(s.values)-1]
+	s.values = s.values[:len(s.values)-1]
+	return v, true
+}
+
+type chanQueue chan int
+
+func (q chanQueue) Enqueue(v int) { q <- v }
+
+func (q chanQueue) Dequeue() (int, bool) {
+	select {
+	case v := <-q:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// benchmarkPutTake runs b.N put and take pairs split over goroutines.
+func benchmarkPutTake(b *testing.B, goroutines int, put func(int), take func() (int, bool)) {
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		go func() {
+			defer wg.Done()
+			for i := g; i < b.N; i += goroutines {
+				put(i)
+				take()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkStack(b *testing.B) {
+	for _, goroutines := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("lockfree/goroutines=%d", goroutines), func(b *testing.B) {
+			var s Stack[int]
+			benchmarkPutTake(b, goroutines, s.Push, s.Pop)
+		})
+		b.Run(fmt.Sprintf("mutex/goroutines=%d", goroutines), func(b *testing.B) {
+			var s mutexStack
+			benchmarkPutTake(b, goroutines, s.Push, s.Pop)
+		})
+	}
+}
+
+func BenchmarkQueue(b *testing.B) {
+	for _, goroutines := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("lockfree/goroutines=%d", goroutines), func(b *testing.B) {
+			q := NewQueue[int]()
+			benchmarkPutTake(b, goroutines, q.Enqueue, q.Dequeue)
+		})
+		b.Run(fmt.Sprintf("channel/goroutines=%d", goroutines), func(b *testing.B) {
+			q := make(chanQueue, goroutines)
+			benchmarkPutTake(b, goroutines, q.Enqueue, q.Dequeue)
+		})
+	}
+}
+
+func ExampleStack() {
+	var s Stack[string]
+	s.Push("a")
+	s.Push("b")
+	v, _ := s.Pop()
+	fmt.Println(v)
+	// Output: b
+}
@@ -0,0 +1,70 @@
+package lockfree
+
+import "sync/atomic"
+
+// Queue is a first-in, first-out list, the algorithm of Michael and Scott.
+// Queues are created with NewQueue.
+//
+// The queue always holds a dummy node at its head, so that enqueuers only
+// touch the tail and dequeuers only touch the head. A goroutine that finds
+// the tail lagging behind the last node moves it forward before retrying,
+// so no goroutine waits on another one that was interrupted mid-update.
+type Queue[T any] struct {
+	head atomic.Pointer[queueNode[T]]
+	tail atomic.Pointer[queueNode[T]]
+}
+
+type queueNode[T any] struct {
+	value T
+	next  atomic.Pointer[queueNode[T]]
+}
+
+// NewQueue returns an empty queue.
+func NewQueue[T any]() *Queue[T] {
+	q := new(Queue[T])
+	dummy := new(queueNode[T])
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
+	return q
+}
+
+// Enqueue adds v to the back of the queue.
+func (q *Queue[T]) Enqueue(v T) {
+	n := &queueNode[T]{value: v}
+	for {
+		tail := q.tail.Load()
+		next := tail.next.Load()
+		if next != nil {
+			// another enqueuer linked a node but hasn't moved the tail yet
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, n) {
+			q.tail.CompareAndSwap(tail, n)
+			return
+		}
+	}
+}
+
+// Dequeue removes the value at the front of the queue and returns it. It
+// returns false if the queue is empty.
+func (q *Queue[T]) Dequeue() (T, bool) {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
+		if next == nil {
+			var zero T
+			return zero, false
+		}
+		if head == tail {
+			// the tail lags behind, help the enqueuer before taking the node
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if q.head.CompareAndSwap(head, next) {
+			// next becomes the new dummy node
+			return next.value, true
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package lockfree
+
+import "sync/atomic"
+
+// Stack is a last-in, first-out list, also known as a Treiber stack. The
+// zero value is an empty stack.
+type Stack[T any] struct {
+	top atomic.Pointer[stackNode[T]]
+}
+
+type stackNode[T any] struct {
+	value T
+	next  *stackNode[T]
+}
+
+// Push adds v to the top of the stack.
+func (s *Stack[T]) Push(v T) {
+	n := &stackNode[T]{value: v}
+	for {
+		n.next = s.top.Load()
+		if s.top.CompareAndSwap(n.next, n) {
+			return
+		}
+	}
+}
+
+// Pop removes the value at the top of the stack and returns it. It returns
+// false if the stack is empty.
+//
+// A node is never reused while another goroutine may still hold a pointer
+// to it, since the garbage collector keeps it alive. That rules out the ABA
+// problem of stacks in languages that free and reuse their nodes.
+func (s *Stack[T]) Pop() (T, bool) {
+	for {
+		top := s.top.Load()
+		if top == nil {
+			var zero T
+			return zero, false
+		}
+		if s.top.CompareAndSwap(top, top.next) {
+			return top.value, true
+		}
+	}
+}
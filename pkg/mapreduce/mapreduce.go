@@ -0,0 +1,281 @@
+// Package mapreduce counts the words in the files of a directory tree the
+// way MapReduce does.
+//
+// The files lesson scans one file word by word with bufio.ScanWords. WordCount
+// does that for every file under a directory at once, in three phases:
+//
+//   - map: every file is counted on its own by a job on a workerpool.Pool,
+//   - shuffle: the counts of a file are split by word, so that every word
+//     always goes to the same reducer,
+//   - reduce: each reducer adds up the counts of its share of the words.
+//
+// No two reducers see the same word, so they need no locks, and their
+// results are put together without adding anything up again.
+package mapreduce
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"hash/maphash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ssukruth/little-engine-go/pkg/workerpool"
+)
+
+// Option configures WordCount.
+type Option func(*config)
+
+type config struct {
+	mappers  int
+	reducers int
+	match    string
+}
+
+// Mappers sets how many files are counted at the same time, GOMAXPROCS by
+// default.
+func Mappers(n int) Option {
+	return func(c *config) { c.mappers = n }
+}
+
+// Reducers sets how many goroutines add up the counts, GOMAXPROCS by
+// default.
+func Reducers(n int) Option {
+	return func(c *config) { c.reducers = n }
+}
+
+// Match only counts the files whose name matches pattern, in the syntax of
+// filepath.Match, like "*.txt". Every file is counted by default.
+func Match(pattern string) Option {
+	return func(c *config) { c.match = pattern }
+}
+
+// Result holds the word counts of a directory tree.
+type Result struct {
+	Files  int            // files counted
+	Words  int            // words counted, including repeated ones
+	Counts map[string]int // occurrences of each distinct word
+	// LongRuns counts the runs of non-space bytes of bufio.MaxScanTokenSize
+	// or more, like in minified or binary files, which are skipped rather
+	// than counted as words.
+	LongRuns int
+}
+
+// Count is the number of occurrences of a word.
+type Count struct {
+	Word string
+	N    int
+}
+
+// Top returns the n most frequent words, most frequent first and words that
+// occur equally often in alphabetical order. It returns every word if there
+// are fewer than n.
+func (r Result) Top(n int) []Count {
+	counts := make([]Count, 0, len(r.Counts))
+	for w, c := range r.Counts {
+		counts = append(counts, Count{w, c})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].N != counts[j].N {
+			return counts[i].N > counts[j].N
+		}
+		return counts[i].Word < counts[j].Word
+	})
+	return counts[:min(max(n, 0), len(counts))]
+}
+
+// WordCount counts the words of every regular file under dir. Words are
+// separated by white space like with bufio.ScanWords, lower cased and
+// stripped of leading and trailing characters that are neither letters nor
+// digits, so "Hello," and "hello" are the same word. WordCount returns the
+// first error reading a file, or ctx.Err() if ctx is done first.
+func WordCount(ctx context.Context, dir string, opts ...Option) (Result, error) {
+	c := config{mappers: runtime.GOMAXPROCS(0), reducers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.mappers < 1 || c.reducers < 1 {
+		return Result{}, fmt.Errorf("mapreduce: need at least 1 mapper and reducer, have %d and %d", c.mappers, c.reducers)
+	}
+	if c.match != "" {
+		if _, err := filepath.Match(c.match, ""); err != nil {
+			return Result{}, fmt.Errorf("mapreduce: %w", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// reduce
+	seed := maphash.MakeSeed()
+	shares := make([]chan map[string]int, c.reducers)
+	reduced := make([]map[string]int, c.reducers)
+	var reducers sync.WaitGroup
+	for r := range shares {
+		shares[r] = make(chan map[string]int, 1)
+		reducers.Add(1)
+		go func() {
+			defer reducers.Done()
+			counts := make(map[string]int)
+			for share := range shares[r] {
+				for w, n := range share {
+					counts[w] += n
+				}
+			}
+			reduced[r] = counts
+		}()
+	}
+
+	// map
+	pool := workerpool.New[fileCounts](c.mappers, c.mappers)
+	defer pool.Shutdown(context.Background())
+	tasks := make(chan *workerpool.Task[fileCounts], c.mappers)
+	var walkErr error
+	go func() {
+		defer close(tasks)
+		walkErr = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if c.match != "" {
+				if ok, _ := filepath.Match(c.match, d.Name()); !ok {
+					return nil
+				}
+			}
+			task, err := pool.Submit(ctx, func(ctx context.Context) (fileCounts, error) {
+				return countFile(ctx, path)
+			})
+			if err != nil {
+				return err
+			}
+			tasks <- task
+			return nil
+		})
+	}()
+
+	// shuffle, in the order the files were walked
+	res := Result{Counts: make(map[string]int)}
+	var err error
+	for task := range tasks {
+		// the job returns soon after ctx is cancelled, no need to wait on it
+		fc, taskErr := task.Wait(context.Background())
+		if err != nil {
+			continue
+		}
+		if taskErr != nil {
+			err = taskErr
+			cancel()
+			continue
+		}
+		res.Files++
+		res.LongRuns += fc.longRuns
+		split := make([]map[string]int, c.reducers)
+		for w, n := range fc.counts {
+			res.Words += n
+			r := maphash.String(seed, w) % uint64(c.reducers)
+			if split[r] == nil {
+				split[r] = make(map[string]int)
+			}
+			split[r][w] = n
+		}
+		for r, share := range split {
+			if share != nil {
+				shares[r] <- share
+			}
+		}
+	}
+	for _, share := range shares {
+		close(share)
+	}
+	reducers.Wait()
+	if err == nil {
+		err = walkErr
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	// the reducers saw disjoint words, so their counts are final
+	for _, counts := range reduced {
+		for w, n := range counts {
+			res.Counts[w] = n
+		}
+	}
+	return res, nil
+}
+
+// fileCounts are the word counts of a single file.
+type fileCounts struct {
+	counts   map[string]int
+	longRuns int
+}
+
+// countFile counts the words of the file at path.
+func countFile(ctx context.Context, path string) (fileCounts, error) {
+	fc := fileCounts{counts: make(map[string]int)}
+	f, err := os.Open(path)
+	if err != nil {
+		return fc, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Split(scanWords(&fc.longRuns))
+	for i := 0; s.Scan(); i++ {
+		// look at ctx now and then, not for every word
+		if i%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return fc, err
+			}
+		}
+		if w := normalize(s.Text()); w != "" {
+			fc.counts[w]++
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fc, fmt.Errorf("mapreduce: %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// scanWords is bufio.ScanWords for a Scanner with the default buffer, except
+// that runs of non-space bytes that fill the whole buffer are skipped and
+// counted in longRuns, instead of failing the scan with bufio.ErrTooLong.
+func scanWords(longRuns *int) bufio.SplitFunc {
+	skipping := false // dropping the rest of a long run
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if skipping {
+			i := bytes.IndexFunc(data, unicode.IsSpace)
+			if i < 0 {
+				return len(data), nil, nil
+			}
+			skipping = false
+			data = data[i:]
+			adv, token, err := bufio.ScanWords(data, atEOF)
+			return i + adv, token, err
+		}
+		adv, token, err := bufio.ScanWords(data, atEOF)
+		// ScanWords skips leading spaces, so without an advance data is a
+		// single run without an end in sight
+		if adv == 0 && token == nil && err == nil && len(data) >= bufio.MaxScanTokenSize {
+			*longRuns++
+			skipping = true
+			return len(data), nil, nil
+		}
+		return adv, token, err
+	}
+}
+
+func normalize(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
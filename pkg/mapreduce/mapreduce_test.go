@@ -0,0 +1,239 @@
+package mapreduce
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+// writeFiles creates the files under dir, which maps slash separated paths
+// to their contents.
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	leakcheck.Check(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":         "the quick brown fox\njumps over the lazy dog\n",
+		"b.txt":         "The dog barks. The fox runs!",
+		"sub/c.txt":     "  (the)  \"fox\"  ",
+		"sub/deep/d.md": "the end",
+		"empty.txt":     "",
+	})
+	res, err := WordCount(context.Background(), dir, Mappers(2), Reducers(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 5 {
+		t.Errorf("Files = %d, want 5", res.Files)
+	}
+	if res.Words != 19 {
+		t.Errorf("Words = %d, want 19", res.Words)
+	}
+	want := []Count{{"the", 6}, {"fox", 3}, {"dog", 2}, {"barks", 1}}
+	if got := res.Top(4); !reflect.DeepEqual(got, want) {
+		t.Errorf("Top(4) = %v, want %v", got, want)
+	}
+	if n := len(res.Top(100)); n != len(res.Counts) {
+		t.Errorf("Top(100) returned %d of %d words", n, len(res.Counts))
+	}
+}
+
+func TestWordCountMatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":     "go go",
+		"b.md":      "go",
+		"sub/c.txt": "go",
+	})
+	res, err := WordCount(context.Background(), dir, Match("*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 2 || res.Counts["go"] != 3 {
+		t.Errorf("counted %d files and %d times go, want 2 and 3", res.Files, res.Counts["go"])
+	}
+	if _, err := WordCount(context.Background(), dir, Match("[")); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("WordCount with a bad pattern = %v, want %v", err, filepath.ErrBadPattern)
+	}
+}
+
+func TestWordCountMatchesSequential(t *testing.T) {
+	leakcheck.Check(t)
+	dir := t.TempDir()
+	writeCorpus(t, dir, 40, 2000)
+	want, err := countSequential(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range [][2]int{{1, 1}, {1, 4}, {4, 1}, {8, 3}} {
+		res, err := WordCount(context.Background(), dir, Mappers(n[0]), Reducers(n[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !maps.Equal(res.Counts, want) {
+			t.Errorf("mappers=%d reducers=%d: counts differ from counting one file after the other", n[0], n[1])
+		}
+		if res.Files != 40 || res.Words != 40*2000 {
+			t.Errorf("mappers=%d reducers=%d: counted %d files and %d words, want 40 and %d", n[0], n[1], res.Files, res.Words, 40*2000)
+		}
+	}
+}
+
+func TestWordCountErrors(t *testing.T) {
+	leakcheck.Check(t)
+	if _, err := WordCount(context.Background(), filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("WordCount of a missing directory = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := WordCount(context.Background(), t.TempDir(), Mappers(0)); err == nil {
+		t.Error("WordCount with 0 mappers succeeded")
+	}
+
+	dir := t.TempDir()
+	writeCorpus(t, dir, 20, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := WordCount(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("WordCount with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWordCountSkipsLongRuns(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("x", 3*bufio.MaxScanTokenSize)
+	writeFiles(t, dir, map[string]string{
+		"min.js":   "before " + long + " after\n" + long,
+		"word.txt": "almost " + strings.Repeat("y", bufio.MaxScanTokenSize-1),
+	})
+	res, err := WordCount(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.LongRuns != 2 {
+		t.Errorf("LongRuns = %d, want 2", res.LongRuns)
+	}
+	want := map[string]int{"before": 1, "after": 1, "almost": 1, strings.Repeat("y", bufio.MaxScanTokenSize-1): 1}
+	if !maps.Equal(res.Counts, want) {
+		t.Errorf("counted %d distinct words, want the 4 around the long runs", len(res.Counts))
+	}
+}
+
+func TestTop(t *testing.T) {
+	r := Result{Counts: map[string]int{"b": 2, "a": 2, "c": 3, "d": 1}}
+	if got, want := r.Top(3), []Count{{"c", 3}, {"a", 2}, {"b", 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Top(3) = %v, want %v", got, want)
+	}
+	if got := r.Top(-1); len(got) != 0 {
+		t.Errorf("Top(-1) = %v, want none", got)
+	}
+}
+
+// countSequential counts the words of the files under dir one after the
+// other, without goroutines.
+func countSequential(dir string) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		fc, err := countFile(context.Background(), path)
+		for w, n := range fc.counts {
+			counts[w] += n
+		}
+		return err
+	})
+	return counts, err
+}
+
+// writeCorpus writes files files of words words each under dir. The words
+// are drawn from a vocabulary of 10000 with a Zipf distribution, so that
+// like in real text a few words are very frequent and most are rare. The
+// corpus is the same on every call.
+func writeCorpus(t testing.TB, dir string, files, words int) {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rnd, 1.1, 1, 9999)
+	for f := 0; f < files; f++ {
+		var sb strings.Builder
+		for w := 0; w < words; w++ {
+			sb.WriteString(fmt.Sprintf("w%d", zipf.Uint64()))
+			if w%12 == 11 {
+				sb.WriteString(".\n")
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		// spread the files over a few directories
+		path := filepath.Join(dir, fmt.Sprintf("d%d", f%8), fmt.Sprintf("f%04d.txt", f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWordCount counts a generated corpus of 200 files of 10000 words
+// with different numbers of mappers, against counting one file after the
+// other.
+func BenchmarkWordCount(b *testing.B) {
+	dir := b.TempDir()
+	writeCorpus(b, dir, 200, 10000)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := countSequential(dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	for _, mappers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("mappers=%d", mappers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := WordCount(context.Background(), dir, Mappers(mappers), Reducers(4)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// The scratchpad of the files lesson, counted.
+func Example() {
+	res, err := WordCount(context.Background(), "../../0011_files/scratchpad", Match("*.txt"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(res.Files, "files,", res.Words, "words")
+	for _, c := range res.Top(3) {
+		fmt.Println(c.Word, c.N)
+	}
+	// Output:
+	// 4 files, 23 words
+	// line 2
+	// a 1
+	// all 1
+}
@@ -0,0 +1,305 @@
+// Package pipeline connects typed stages with channels.
+//
+// Every stage runs in goroutines of a Pipeline, reads from the channel
+// returned by the stage before it and closes its own output channel when it
+// is done:
+//
+//	p, ctx := pipeline.New(ctx)
+//	lines := pipeline.From(p, files...)
+//	words := pipeline.FanOut(p, lines, 8, count)
+//	pipeline.Sink(p, pipeline.FanIn(p, words...), print)
+//	err := p.Wait()
+//
+// The first stage to return an error cancels the pipeline's context, which
+// stops every other stage, and Wait returns that error. Cancelling the
+// context passed to New stops the pipeline the same way.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Pipeline runs the goroutines of the stages and collects the first error.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// New returns an empty pipeline and the context its stages run with, which
+// is derived from ctx.
+func New(ctx context.Context) (*Pipeline, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go runs f in a new goroutine of the pipeline. Custom stages use it like
+// the stages of this package do. A panic in f is reported as an error.
+func (p *Pipeline) Go(f func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := call(p.ctx, f); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+func call(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pipeline: stage panicked: %v", r)
+		}
+	}()
+	return f(ctx)
+}
+
+func (p *Pipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// Wait blocks until every stage has returned and returns the first error,
+// or the error of the parent context if it was cancelled.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	// a cancelled parent makes the stages return early without an error
+	if err := p.ctx.Err(); err != nil {
+		p.fail(err)
+	}
+	p.cancel()
+	return p.err
+}
+
+// send sends v on c unless ctx is done first.
+func send[T any](ctx context.Context, c chan<- T, v T) bool {
+	select {
+	case c <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv receives from c unless ctx is done first. ok is false once c is
+// closed or ctx is done.
+func recv[T any](ctx context.Context, c <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-c:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// From is a source stage emitting items.
+func From[T any](p *Pipeline, items ...T) <-chan T {
+	return Generate(p, func(ctx context.Context, emit func(T) bool) error {
+		for _, v := range items {
+			if !emit(v) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Generate is a source stage emitting what gen passes to emit. emit returns
+// false once the pipeline is cancelled, after which gen should return.
+func Generate[T any](p *Pipeline, gen func(ctx context.Context, emit func(T) bool) error) <-chan T {
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		return gen(ctx, func(v T) bool { return send(ctx, out, v) })
+	})
+	return out
+}
+
+// Map applies f to every value of in, one at a time.
+func Map[T, R any](p *Pipeline, in <-chan T, f func(ctx context.Context, v T) (R, error)) <-chan R {
+	out := make(chan R)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			r, err := f(ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(ctx, out, r) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Filter passes on the values of in that keep returns true for.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(v T) bool) <-chan T {
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			if keep(v) && !send(ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Batch groups the values of in into slices of size values. The last batch
+// holds the rest and may be shorter. Batch panics if size is less than 1.
+func Batch[T any](p *Pipeline, in <-chan T, size int) <-chan []T {
+	if size < 1 {
+		panic("pipeline: batch size must be at least 1")
+	}
+	out := make(chan []T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		batch := make([]T, 0, size)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			batch = append(batch, v)
+			if len(batch) == size {
+				if !send(ctx, out, batch) {
+					return nil
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		if len(batch) > 0 && ctx.Err() == nil {
+			send(ctx, out, batch)
+		}
+		return nil
+	})
+	return out
+}
+
+// Indexed is a value along with the position of the input it was computed
+// from, so that FanInOrdered can restore the order of the input.
+type Indexed[T any] struct {
+	Index int
+	Value T
+}
+
+// FanOut applies f to the values of in on n workers and returns the output
+// channel of each worker. Join them with FanIn or FanInOrdered.
+func FanOut[T, R any](p *Pipeline, in <-chan T, n int, f func(ctx context.Context, v T) (R, error)) []<-chan Indexed[R] {
+	if n < 1 {
+		panic("pipeline: fan-out needs at least 1 worker")
+	}
+	// number the values before handing them out
+	indexed := make(chan Indexed[T])
+	p.Go(func(ctx context.Context) error {
+		defer close(indexed)
+		for i := 0; ; i++ {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, indexed, Indexed[T]{i, v}) {
+				return nil
+			}
+		}
+	})
+	outs := make([]<-chan Indexed[R], n)
+	for w := range outs {
+		outs[w] = Map(p, indexed, func(ctx context.Context, v Indexed[T]) (Indexed[R], error) {
+			r, err := f(ctx, v.Value)
+			return Indexed[R]{v.Index, r}, err
+		})
+	}
+	return outs
+}
+
+// FanIn merges the outputs of FanOut in the order the workers finish.
+func FanIn[T any](p *Pipeline, ins ...<-chan Indexed[T]) <-chan T {
+	return Map(p, Merge(p, ins...), func(_ context.Context, v Indexed[T]) (T, error) {
+		return v.Value, nil
+	})
+}
+
+// FanInOrdered merges the outputs of FanOut in the order of FanOut's input.
+// Values that are done early wait for the ones before them.
+func FanInOrdered[T any](p *Pipeline, ins ...<-chan Indexed[T]) <-chan T {
+	merged := Merge(p, ins...)
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		pending := map[int]T{}
+		next := 0
+		for {
+			v, ok := recv(ctx, merged)
+			if !ok {
+				return nil
+			}
+			pending[v.Index] = v.Value
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if !send(ctx, out, r) {
+					return nil
+				}
+			}
+		}
+	})
+	return out
+}
+
+// Merge sends the values of all ins on one channel, in no particular order.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	p.Go(func(context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Sink calls f for every value of in. It is the last stage of a pipeline;
+// Wait returns once it has seen every value.
+func Sink[T any](p *Pipeline, in <-chan T, f func(ctx context.Context, v T) error) {
+	p.Go(func(ctx context.Context) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			if err := f(ctx, v); err != nil {
+				return err
+			}
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func square(_ context.Context, v int) (int, error) {
+	return v * v, nil
+}
+
+// jitter squares v after a random delay, so that workers finish out of order.
+func jitter(_ context.Context, v int) (int, error) {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+	return v * v, nil
+}
+
+func collect[T any](p *Pipeline, in <-chan T) *[]T {
+	var out []T
+	Sink(p, in, func(_ context.Context, v T) error {
+		out = append(out, v)
+		return nil
+	})
+	return &out
+}
+
+func TestStages(t *testing.T) {
+	leakcheck.Check(t)
+	p, _ := New(context.Background())
+	nums := Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 1; i <= 10; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+		return nil
+	})
+	odd := Filter(p, nums, func(v int) bool { return v%2 == 1 })
+	squares := Map(p, odd, square)
+	batches := collect(p, Batch(p, squares, 2))
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(*batches); got != "[[1 9] [25 49] [81]]" {
+		t.Errorf("batches = %s", got)
+	}
+}
+
+func TestFanIn(t *testing.T) {
+	leakcheck.Check(t)
+	var in []int
+	var want []int
+	for i := 0; i < 200; i++ {
+		in = append(in, i)
+		want = append(want, i*i)
+	}
+
+	p, _ := New(context.Background())
+	ordered := collect(p, FanInOrdered(p, FanOut(p, From(p, in...), 8, jitter)...))
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*ordered, want) {
+		t.Errorf("FanInOrdered = %v", *ordered)
+	}
+
+	p, _ = New(context.Background())
+	unordered := collect(p, FanIn(p, FanOut(p, From(p, in...), 8, jitter)...))
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	got := slices.Clone(*unordered)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("FanIn = %v", *unordered)
+	}
+}
+
+func TestErrorCancels(t *testing.T) {
+	leakcheck.Check(t)
+	errBad := errors.New("bad input")
+	p, ctx := New(context.Background())
+	// an endless source, only the error can stop it
+	nums := Generate(p, func(ctx context.Context, emit func(string) bool) error {
+		for i := 0; emit(strconv.Itoa(i)); i++ {
+		}
+		return nil
+	})
+	parsed := FanOut(p, nums, 4, func(_ context.Context, s string) (int, error) {
+		if s == "100" {
+			return 0, errBad
+		}
+		return strconv.Atoi(s)
+	})
+	var seen int
+	Sink(p, FanInOrdered(p, parsed...), func(context.Context, int) error {
+		seen++
+		return nil
+	})
+	if err := p.Wait(); err != errBad {
+		t.Errorf("Wait = %v, want %v", err, errBad)
+	}
+	if ctx.Err() == nil {
+		t.Error("the pipeline's context wasn't cancelled")
+	}
+	if seen > 100 {
+		t.Errorf("the sink saw %d values, the error came at the 101st", seen)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	leakcheck.Check(t)
+	p, _ := New(context.Background())
+	collect(p, Map(p, From(p, 1, 2, 3), func(context.Context, int) (int, error) {
+		panic("boom")
+	}))
+	if err := p.Wait(); err == nil || err.Error() != "pipeline: stage panicked: boom" {
+		t.Errorf("Wait = %v", err)
+	}
+}
+
+func TestParentCancelled(t *testing.T) {
+	leakcheck.Check(t)
+	parent, cancel := context.WithCancel(context.Background())
+	p, _ := New(parent)
+	n := 0
+	Sink(p, Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for emit(0) {
+		}
+		return nil
+	}), func(context.Context, int) error {
+		if n++; n == 50 {
+			cancel()
+		}
+		return nil
+	})
+	if err := p.Wait(); err != context.Canceled {
+		t.Errorf("Wait = %v, want Canceled", err)
+	}
+}
+
+func Example() {
+	p, _ := New(context.Background())
+	words := From(p, "pipelines", "are", "stages", "connected", "by", "channels")
+	long := Filter(p, words, func(w string) bool { return len(w) > 3 })
+	lengths := FanOut(p, long, 3, func(_ context.Context, w string) (string, error) {
+		return fmt.Sprint(w, " ", len(w)), nil
+	})
+	Sink(p, FanInOrdered(p, lengths...), func(_ context.Context, s string) error {
+		fmt.Println(s)
+		return nil
+	})
+	if err := p.Wait(); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// pipelines 9
+	// stages 6
+	// connected 9
+	// channels 8
+}
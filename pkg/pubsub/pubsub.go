@@ -0,0 +1,338 @@
+// Package pubsub is an in-process broker that delivers the messages
+// published on a topic to every subscriber of the topic.
+//
+// The select example of the concurrency lesson has a goroutine waiting on
+// two channels at once. A Broker turns that around: a publisher sends to one
+// topic and the broker fans the message out to any number of subscriber
+// channels. Each subscriber has its own buffer and Policy for when the buffer
+// is full, so a slow subscriber either holds up the publisher or loses
+// messages, but never both, and how often that happens shows in its Stats.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned when publishing on a closed broker.
+var ErrClosed = errors.New("pubsub: broker is closed")
+
+// Policy is what Publish does when the buffer of a subscriber is full.
+type Policy int
+
+const (
+	// Block waits until the subscriber makes room, or the context of
+	// Publish is done. No message is lost, but a slow subscriber slows
+	// down the publisher.
+	Block Policy = iota
+	// DropOldest discards the oldest buffered message to make room, so
+	// the subscriber sees the latest messages.
+	DropOldest
+	// DropNewest discards the message being published, so the subscriber
+	// sees the messages it has fallen behind on first.
+	DropNewest
+)
+
+var policies = [...]string{"block", "drop-oldest", "drop-newest"}
+
+func (p Policy) String() string {
+	if p < 0 || int(p) >= len(policies) {
+		return "Policy(" + strconv.Itoa(int(p)) + ")"
+	}
+	return policies[p]
+}
+
+// Option configures a subscription.
+type Option func(*options)
+
+type options struct {
+	name   string
+	buffer int
+	policy Policy
+}
+
+// Name names the subscription in its Stats.
+func Name(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// Buffer sets how many messages may wait for the subscriber, 16 by default.
+// With 0 a Block subscriber takes each message straight from the publisher;
+// the drop policies need a buffer of at least 1 and get one.
+func Buffer(n int) Option {
+	return func(o *options) { o.buffer = n }
+}
+
+// WithPolicy sets what happens when the buffer is full, Block by default.
+func WithPolicy(p Policy) Option {
+	return func(o *options) { o.policy = p }
+}
+
+// Broker delivers messages of type T. The zero value is not usable, brokers
+// are created with New.
+type Broker[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription[T]]struct{}
+	closed bool
+}
+
+// New returns a broker without topics.
+func New[T any]() *Broker[T] {
+	return &Broker[T]{topics: make(map[string]map[*Subscription[T]]struct{})}
+}
+
+// Subscribe subscribes to topic. Messages published from now on arrive on
+// the channel of the subscription until it is unsubscribed or the broker is
+// closed. Subscribing to a closed broker returns a subscription whose
+// channel is closed.
+func (b *Broker[T]) Subscribe(topic string, opts ...Option) *Subscription[T] {
+	o := options{buffer: 16, policy: Block}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.policy != Block && o.buffer < 1 {
+		o.buffer = 1
+	}
+	s := &Subscription[T]{
+		b:      b,
+		topic:  topic,
+		name:   o.name,
+		policy: o.policy,
+		ch:     make(chan T, o.buffer),
+		done:   make(chan struct{}),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.close()
+		return s
+	}
+	subs := b.topics[topic]
+	if subs == nil {
+		subs = make(map[*Subscription[T]]struct{})
+		b.topics[topic] = subs
+	}
+	subs[s] = struct{}{}
+	return s
+}
+
+// Publish delivers msg to every subscriber of topic. It returns when msg was
+// buffered for or dropped by each of them. If a subscriber with the Block
+// policy has no room, Publish waits for it and returns ctx.Err() if ctx is
+// done first. Only the Block subscribers without room lose msg then: the
+// ones with a drop policy get it first, and the other Block subscribers
+// still get it if they have room.
+func (b *Broker[T]) Publish(ctx context.Context, topic string, msg T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	// Deliver outside the lock so that a blocked delivery doesn't keep
+	// subscribers from unsubscribing.
+	// The drop policies never wait, so they go first and a blocked
+	// subscriber can't hold up the others.
+	var subs, blocking []*Subscription[T]
+	for s := range b.topics[topic] {
+		if s.policy == Block {
+			blocking = append(blocking, s)
+		} else {
+			subs = append(subs, s)
+		}
+	}
+	subs = append(subs, blocking...)
+	b.mu.RUnlock()
+
+	// deliver only fails with ctx.Err(), which is the same for every
+	// subscriber, so keep the first one
+	var err error
+	for _, s := range subs {
+		if e := s.deliver(ctx, msg); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Topics returns the topics that have subscribers, sorted.
+func (b *Broker[T]) Topics() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	topics := make([]string, 0, len(b.topics))
+	for t := range b.topics {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Stats returns the stats of every subscription, sorted by topic and name.
+func (b *Broker[T]) Stats() []Stats {
+	b.mu.RLock()
+	var stats []Stats
+	for _, subs := range b.topics {
+		for s := range subs {
+			stats = append(stats, s.Stats())
+		}
+	}
+	b.mu.RUnlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Topic != stats[j].Topic {
+			return stats[i].Topic < stats[j].Topic
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// Close unsubscribes every subscriber and makes Publish return ErrClosed.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	topics := b.topics
+	b.topics = make(map[string]map[*Subscription[T]]struct{})
+	b.mu.Unlock()
+	for _, subs := range topics {
+		for s := range subs {
+			s.close()
+		}
+	}
+}
+
+// Stats shows how well a subscriber keeps up with its topic.
+type Stats struct {
+	Topic     string
+	Name      string
+	Policy    Policy
+	Delivered uint64        // messages put in the buffer, including ones DropOldest dropped later
+	Dropped   uint64        // messages discarded because the buffer was full
+	Blocked   time.Duration // time publishers waited for room in the buffer
+	Waiting   int           // publishers waiting for room in the buffer now
+	Pending   int           // messages in the buffer now
+	Capacity  int           // size of the buffer
+}
+
+// Subscription is a subscriber to a topic.
+type Subscription[T any] struct {
+	b      *Broker[T]
+	topic  string
+	name   string
+	policy Policy
+	ch     chan T
+	// done is closed on Unsubscribe to release publishers blocked on ch.
+	done chan struct{}
+	once sync.Once
+	// sending is read locked by deliveries and write locked to close ch,
+	// which must not happen during a send.
+	sending sync.RWMutex
+	closed  bool
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	blocked   atomic.Int64 // nanoseconds
+	waiting   atomic.Int32
+}
+
+// C returns the channel the messages arrive on. It is closed once the
+// subscription ends and the buffered messages are received.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Topic returns the topic of the subscription.
+func (s *Subscription[T]) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe ends the subscription. Publishers blocked on it move on, and
+// its channel is closed after the messages already buffered.
+func (s *Subscription[T]) Unsubscribe() {
+	s.b.mu.Lock()
+	if subs := s.b.topics[s.topic]; subs != nil {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(s.b.topics, s.topic)
+		}
+	}
+	s.b.mu.Unlock()
+	s.close()
+}
+
+func (s *Subscription[T]) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.sending.Lock()
+		defer s.sending.Unlock()
+		s.closed = true
+		close(s.ch)
+	})
+}
+
+// Stats returns the stats of the subscription.
+func (s *Subscription[T]) Stats() Stats {
+	return Stats{
+		Topic:     s.topic,
+		Name:      s.name,
+		Policy:    s.policy,
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Blocked:   time.Duration(s.blocked.Load()),
+		Waiting:   int(s.waiting.Load()),
+		Pending:   len(s.ch),
+		Capacity:  cap(s.ch),
+	}
+}
+
+func (s *Subscription[T]) deliver(ctx context.Context, msg T) error {
+	s.sending.RLock()
+	defer s.sending.RUnlock()
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.ch <- msg:
+		s.delivered.Add(1)
+		return nil
+	default:
+	}
+
+	switch s.policy {
+	case DropNewest:
+		s.dropped.Add(1)
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- msg:
+				s.delivered.Add(1)
+				return nil
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+				// the subscriber made room in the meantime
+			}
+		}
+	default:
+		start := time.Now()
+		s.waiting.Add(1)
+		defer func() {
+			s.waiting.Add(-1)
+			s.blocked.Add(int64(time.Since(start)))
+		}()
+		select {
+		case s.ch <- msg:
+			s.delivered.Add(1)
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
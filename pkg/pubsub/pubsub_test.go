@@ -0,0 +1,271 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+// drain returns the messages left on the channel of s after unsubscribing.
+func drain[T any](s *Subscription[T]) []T {
+	s.Unsubscribe()
+	var msgs []T
+	for m := range s.C() {
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func publish(t *testing.T, b *Broker[int], topic string, msgs ...int) {
+	t.Helper()
+	for _, m := range msgs {
+		if err := b.Publish(context.Background(), topic, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTopics(t *testing.T) {
+	b := New[int]()
+	s1 := b.Subscribe("a")
+	s2 := b.Subscribe("a")
+	s3 := b.Subscribe("b")
+	publish(t, b, "a", 1, 2)
+	publish(t, b, "b", 3)
+	publish(t, b, "nobody", 4)
+	if got := b.Topics(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Topics() = %v", got)
+	}
+	for _, tt := range []struct {
+		s    *Subscription[int]
+		want []int
+	}{{s1, []int{1, 2}}, {s2, []int{1, 2}}, {s3, []int{3}}} {
+		if got := drain(tt.s); !slices.Equal(got, tt.want) {
+			t.Errorf("subscriber of %s got %v, want %v", tt.s.Topic(), got, tt.want)
+		}
+	}
+	if got := b.Topics(); len(got) != 0 {
+		t.Errorf("Topics() after unsubscribing = %v", got)
+	}
+}
+
+func TestDropPolicies(t *testing.T) {
+	b := New[int]()
+	oldest := b.Subscribe("t", Buffer(2), WithPolicy(DropOldest))
+	newest := b.Subscribe("t", Buffer(2), WithPolicy(DropNewest))
+	publish(t, b, "t", 1, 2, 3, 4, 5)
+	so, sn := oldest.Stats(), newest.Stats()
+	if so.Dropped != 3 || so.Delivered != 5 || so.Pending != 2 {
+		t.Errorf("drop-oldest stats %+v", so)
+	}
+	if sn.Dropped != 3 || sn.Delivered != 2 || sn.Pending != 2 {
+		t.Errorf("drop-newest stats %+v", sn)
+	}
+	if got := drain(oldest); !slices.Equal(got, []int{4, 5}) {
+		t.Errorf("drop-oldest got %v, want [4 5]", got)
+	}
+	if got := drain(newest); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("drop-newest got %v, want [1 2]", got)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	leakcheck.Check(t)
+	b := New[int]()
+	s := b.Subscribe("t", Buffer(1), Name("slow"))
+	publish(t, b, "t", 1)
+
+	published := make(chan error)
+	go func() { published <- b.Publish(context.Background(), "t", 2) }()
+	select {
+	case err := <-published:
+		t.Fatalf("Publish returned %v while the buffer was full", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if v := <-s.C(); v != 1 {
+		t.Errorf("received %d, want 1", v)
+	}
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Stats(); st.Blocked < 40*time.Millisecond || st.Delivered != 2 || st.Name != "slow" {
+		t.Errorf("stats %+v", st)
+	}
+
+	// a publisher gives up when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, "t", 3); err != context.DeadlineExceeded {
+		t.Errorf("Publish to a full subscriber returned %v, want DeadlineExceeded", err)
+	}
+	if got := drain(s); !slices.Equal(got, []int{2}) {
+		t.Errorf("left on the channel %v, want [2]", got)
+	}
+}
+
+func TestBlockedSubscriberDoesntCostOthersTheMessage(t *testing.T) {
+	leakcheck.Check(t)
+	b := New[int]()
+	full := b.Subscribe("t", Buffer(0), Name("full"))
+	defer full.Unsubscribe()
+	var others []*Subscription[int]
+	for i := 0; i < 4; i++ {
+		others = append(others,
+			b.Subscribe("t", Name(fmt.Sprint("block", i))),
+			b.Subscribe("t", WithPolicy(DropNewest), Name(fmt.Sprint("drop-newest", i))),
+			b.Subscribe("t", WithPolicy(DropOldest), Name(fmt.Sprint("drop-oldest", i))))
+	}
+	// the subscribers are visited in a different order every time
+	for m := 1; m <= 10; m++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		if err := b.Publish(ctx, "t", m); err != context.DeadlineExceeded {
+			t.Errorf("Publish with a full subscriber returned %v, want DeadlineExceeded", err)
+		}
+		cancel()
+	}
+	for _, s := range others {
+		if got := drain(s); !slices.Equal(got, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}) {
+			t.Errorf("%s got %v, want every message", s.Stats().Name, got)
+		}
+	}
+}
+
+// waitForPublisher waits until a publisher is blocked on s.
+func waitForPublisher[T any](t *testing.T, s *Subscription[T]) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.Stats().Waiting == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("publisher didn't block on a full subscriber")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnsubscribeReleasesPublisher(t *testing.T) {
+	leakcheck.Check(t)
+	b := New[int]()
+	s := b.Subscribe("t", Buffer(0))
+	published := make(chan error)
+	go func() { published <- b.Publish(context.Background(), "t", 1) }()
+	waitForPublisher(t, s)
+	s.Unsubscribe()
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish still blocked after Unsubscribe")
+	}
+	if _, ok := <-s.C(); ok {
+		t.Error("channel not closed after Unsubscribe")
+	}
+	s.Unsubscribe() // a second time is fine
+}
+
+func TestPolicyString(t *testing.T) {
+	for p, want := range map[Policy]string{Block: "block", DropOldest: "drop-oldest", DropNewest: "drop-newest", 3: "Policy(3)", -1: "Policy(-1)"} {
+		if got := fmt.Sprint(p); got != want {
+			t.Errorf("Policy %d prints as %q, want %q", int(p), got, want)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := New[int]()
+	s := b.Subscribe("t")
+	publish(t, b, "t", 1)
+	b.Close()
+	if got := drain(s); !slices.Equal(got, []int{1}) {
+		t.Errorf("got %v after Close, want [1]", got)
+	}
+	if err := b.Publish(context.Background(), "t", 2); err != ErrClosed {
+		t.Errorf("Publish after Close returned %v", err)
+	}
+	if _, ok := <-b.Subscribe("t").C(); ok {
+		t.Error("subscription to a closed broker is open")
+	}
+}
+
+func TestStats(t *testing.T) {
+	b := New[string]()
+	b.Subscribe("b", Name("y"))
+	b.Subscribe("a", Name("z"), WithPolicy(DropNewest), Buffer(0))
+	b.Subscribe("b", Name("x"))
+	var got []string
+	for _, s := range b.Stats() {
+		got = append(got, fmt.Sprintf("%s/%s %v %d", s.Topic, s.Name, s.Policy, s.Capacity))
+	}
+	want := []string{"a/z drop-newest 1", "b/x block 16", "b/y block 16"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Stats() = %q, want %q", got, want)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	leakcheck.Check(t)
+	b := New[int]()
+	var wg sync.WaitGroup
+	const publishers, msgs = 4, 500
+	counts := make([]int, 6)
+	for i := range counts {
+		policy := Policy(i % 3)
+		s := b.Subscribe("t", Buffer(4), WithPolicy(policy))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range s.C() {
+				counts[i]++
+				if i == 5 && counts[i] == 100 {
+					s.Unsubscribe()
+				}
+			}
+		}()
+	}
+	var pubs sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		pubs.Add(1)
+		go func() {
+			defer pubs.Done()
+			for m := 0; m < msgs; m++ {
+				if err := b.Publish(context.Background(), "t", m); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	pubs.Wait()
+	b.Close()
+	wg.Wait()
+	for i, n := range counts {
+		if Policy(i%3) == Block && i != 5 && n != publishers*msgs {
+			t.Errorf("blocking subscriber %d got %d messages, want %d", i, n, publishers*msgs)
+		}
+	}
+}
+
+func ExampleBroker() {
+	b := New[string]()
+	defer b.Close()
+	alerts := b.Subscribe("alerts")
+	latest := b.Subscribe("alerts", Buffer(1), WithPolicy(DropOldest))
+
+	ctx := context.Background()
+	b.Publish(ctx, "alerts", "disk full")
+	b.Publish(ctx, "alerts", "disk ok")
+
+	fmt.Println(<-alerts.C(), "/", <-alerts.C())
+	fmt.Println(<-latest.C())
+	fmt.Println("dropped:", latest.Stats().Dropped)
+	// Output:
+	// disk full / disk ok
+	// disk ok
+	// dropped: 1
+}
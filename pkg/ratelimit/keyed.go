@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+)
+
+// Keyed keeps a Limiter for every key, all with the same rate and burst.
+// Limiters that haven't been used for the idle time and are full again are
+// evicted, so keys seen once don't pile up. It is safe for concurrent use.
+type Keyed[K comparable] struct {
+	rate  float64
+	burst int
+	idle  time.Duration
+	clock clock.Clock
+
+	mu        sync.Mutex
+	limiters  map[K]*keyedLimiter
+	lastSweep time.Time
+}
+
+type keyedLimiter struct {
+	*Limiter
+	used time.Time
+}
+
+// NewKeyed returns limiters of rate and burst per key that are evicted after
+// idle without events, on clock c or the real clock if c is nil.
+func NewKeyed[K comparable](rate float64, burst int, idle time.Duration, c clock.Clock) *Keyed[K] {
+	if c == nil {
+		c = clock.Real()
+	}
+	return &Keyed[K]{
+		rate:      rate,
+		burst:     burst,
+		idle:      idle,
+		clock:     c,
+		limiters:  map[K]*keyedLimiter{},
+		lastSweep: c.Now(),
+	}
+}
+
+// Get returns the limiter of key, creating it with a full bucket if there
+// is none.
+func (k *Keyed[K]) Get(key K) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	now := k.clock.Now()
+	// sweep at most once per idle time, which keeps Get cheap
+	if now.Sub(k.lastSweep) >= k.idle {
+		k.evict(now)
+	}
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: New(k.rate, k.burst, k.clock)}
+		k.limiters[key] = l
+	}
+	l.used = now
+	return l.Limiter
+}
+
+// Allow is Limiter.Allow for key.
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait is Limiter.Wait for key.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Len returns the number of keys with a limiter.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// Evict removes the limiters idle for at least the idle time whose bucket
+// has refilled, and returns how many it removed. Get calls it regularly; call it to free memory sooner.
+func (k *Keyed[K]) Evict() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.evict(k.clock.Now())
+}
+
+func (k *Keyed[K]) evict(now time.Time) int {
+	n := 0
+	for key, l := range k.limiters {
+		// a new limiter starts with a full bucket, so evicting one that
+		// hasn't refilled yet would hand the key tokens it doesn't have
+		if now.Sub(l.used) >= k.idle && l.Tokens() >= float64(k.burst) {
+			delete(k.limiters, key)
+			n++
+		}
+	}
+	k.lastSweep = now
+	return n
+}
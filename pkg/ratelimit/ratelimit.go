@@ -0,0 +1,226 @@
+// Package ratelimit caps how often something happens with token buckets.
+//
+// A Limiter holds up to burst tokens and gains rate tokens per second. Every
+// event takes a token: Allow drops events when there is none, Wait blocks
+// until there is one and Reserve tells how long to wait for it. Keyed keeps a
+// Limiter per key, e.g. per client, and forgets the ones that aren't used.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+)
+
+// ErrExceedsBurst is returned by Wait for events that need more tokens than
+// the bucket holds, which would never be allowed.
+var ErrExceedsBurst = errors.New("ratelimit: event exceeds the burst")
+
+const (
+	// Inf is a rate that allows every event.
+	Inf = math.MaxFloat64
+	// InfDuration is the delay of a reservation that can't be fulfilled.
+	InfDuration = time.Duration(math.MaxInt64)
+)
+
+// Every returns the rate of one event per interval.
+func Every(interval time.Duration) float64 {
+	if interval <= 0 {
+		return Inf
+	}
+	return float64(time.Second) / float64(interval)
+}
+
+// Limiter is a token bucket. It is safe for concurrent use.
+type Limiter struct {
+	clock clock.Clock
+	rate  float64 // tokens per second
+	burst int
+
+	mu     sync.Mutex
+	tokens float64   // may be negative while reservations are pending
+	last   time.Time // when tokens was last brought up to date
+	// lastEvent is when the latest reservation's tokens are available
+	lastEvent time.Time
+}
+
+// New returns a full bucket of burst tokens that refills at rate tokens per
+// second, measured on c, or on the real clock if c is nil.
+func New(rate float64, burst int, c clock.Clock) *Limiter {
+	if c == nil {
+		c = clock.Real()
+	}
+	return &Limiter{clock: c, rate: rate, burst: burst, tokens: float64(burst), last: c.Now()}
+}
+
+// Rate returns the tokens added per second.
+func (l *Limiter) Rate() float64 { return l.rate }
+
+// Burst returns the most tokens the bucket holds.
+func (l *Limiter) Burst() int { return l.burst }
+
+// Tokens returns the tokens available now.
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.advance(l.clock.Now())
+}
+
+// advance brings the tokens up to date at now. l.mu must be held.
+func (l *Limiter) advance(now time.Time) float64 {
+	if now.After(l.last) {
+		if l.rate == Inf {
+			l.tokens = float64(l.burst)
+		} else {
+			l.tokens = math.Min(float64(l.burst), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		}
+		l.last = now
+	}
+	return l.tokens
+}
+
+// Allow reports whether an event may happen now and takes a token if so.
+func (l *Limiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN is Allow for an event that takes n tokens.
+func (l *Limiter) AllowN(n int) bool {
+	if l.rate == Inf {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.advance(l.clock.Now()) < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Reservation is a token taken ahead of time.
+type Reservation struct {
+	l      *Limiter
+	ok     bool
+	n      int
+	at     time.Time // when the tokens are available
+	cancel sync.Once
+}
+
+// OK reports whether the tokens could be reserved. An event taking more
+// tokens than the burst can never be.
+func (r *Reservation) OK() bool { return r.ok }
+
+// Delay returns how long to wait before the event may happen.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return InfDuration
+	}
+	return max(r.at.Sub(r.l.clock.Now()), 0)
+}
+
+// Cancel gives the tokens back if the event won't happen after all. Doing
+// so after the tokens became available has no effect. Reservations made
+// after this one were scheduled counting on its tokens being taken, so only
+// the tokens they don't wait for are given back.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.l.rate == Inf {
+		return
+	}
+	r.cancel.Do(func() {
+		l := r.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		now := l.clock.Now()
+		if !now.Before(r.at) {
+			return
+		}
+		// the tokens the later reservations wait for stay taken
+		later := l.lastEvent.Sub(r.at).Seconds() * l.rate
+		restore := float64(r.n) - later
+		if restore <= 0 {
+			return
+		}
+		l.advance(now)
+		l.tokens = math.Min(float64(l.burst), l.tokens+restore)
+		if r.at.Equal(l.lastEvent) {
+			// the latest reservation is now the one before this one
+			prev := r.at.Add(-time.Duration(float64(r.n) / l.rate * float64(time.Second)))
+			if prev.After(now) {
+				l.lastEvent = prev
+			} else {
+				l.lastEvent = now
+			}
+		}
+	})
+}
+
+// Reserve takes a token now, whether or not one is available, and returns
+// when it will be. The caller must either wait for Delay before the event
+// or Cancel the reservation.
+func (l *Limiter) Reserve() *Reservation {
+	return l.ReserveN(1)
+}
+
+// ReserveN is Reserve for an event that takes n tokens.
+func (l *Limiter) ReserveN(n int) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	r := &Reservation{l: l, n: n, at: now}
+	if l.rate == Inf {
+		r.ok = true
+		return r
+	}
+	if n > l.burst {
+		return r
+	}
+	r.ok = true
+	l.tokens = l.advance(now) - float64(n)
+	if l.tokens < 0 {
+		if l.rate <= 0 {
+			// the bucket never refills
+			l.tokens += float64(n)
+			r.ok = false
+			return r
+		}
+		r.at = now.Add(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+	l.lastEvent = r.at
+	return r
+}
+
+// Wait blocks until an event may happen, taking a token. It returns
+// ctx.Err() if ctx is done first, without taking the token.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN is Wait for an event that takes n tokens.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.ReserveN(n)
+	if !r.OK() {
+		return ErrExceedsBurst
+	}
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+	if dl, ok := ctx.Deadline(); ok && dl.Before(r.at) {
+		// don't wait for a token that comes too late
+		r.Cancel()
+		return context.DeadlineExceeded
+	}
+	if err := clock.Sleep(ctx, l.clock, d); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/clock"
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestAllow(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	l := New(1, 3, c)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("event %d of the burst was denied", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Error("event after the burst was allowed")
+	}
+	c.Advance(999 * time.Millisecond)
+	if l.Allow() {
+		t.Error("event allowed before a token was added")
+	}
+	c.Advance(time.Millisecond)
+	if !l.Allow() {
+		t.Error("event denied after a token was added")
+	}
+	// the bucket doesn't fill up beyond the burst
+	c.Advance(time.Hour)
+	if got := l.Tokens(); got != 3 {
+		t.Errorf("Tokens = %v after an hour, want 3", got)
+	}
+	if l.AllowN(4) {
+		t.Error("AllowN(4) allowed with a burst of 3")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	l := New(Every(500*time.Millisecond), 1, c)
+	var delays []time.Duration
+	var rs []*Reservation
+	for i := 0; i < 3; i++ {
+		r := l.Reserve()
+		rs = append(rs, r)
+		delays = append(delays, r.Delay())
+	}
+	want := []time.Duration{0, 500 * time.Millisecond, time.Second}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("reservation %d waits %v, want %v", i, delays[i], want[i])
+		}
+	}
+	rs[2].Cancel()
+	rs[2].Cancel() // only gives the tokens back once
+	if d := l.Reserve().Delay(); d != time.Second {
+		t.Errorf("reservation after a cancel waits %v, want 1s", d)
+	}
+	c.Advance(250 * time.Millisecond)
+	if d := rs[1].Delay(); d != 250*time.Millisecond {
+		t.Errorf("Delay after 250ms = %v", d)
+	}
+	if r := l.ReserveN(2); r.OK() || r.Delay() != InfDuration {
+		t.Error("reserved more than the burst")
+	}
+}
+
+func TestCancelKeepsTokensOfLaterReservations(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	l := New(Every(500*time.Millisecond), 1, c)
+	l.Reserve() // takes the burst
+	r1 := l.Reserve()
+	r2 := l.Reserve()
+	if r1.Delay() != 500*time.Millisecond || r2.Delay() != time.Second {
+		t.Fatalf("reservations wait %v and %v, want 500ms and 1s", r1.Delay(), r2.Delay())
+	}
+	// r2 was scheduled on the token r1 took, which r1 can't give back
+	r1.Cancel()
+	if d := l.Reserve().Delay(); d != 1500*time.Millisecond {
+		t.Errorf("reservation after cancelling an earlier one waits %v, want 1.5s", d)
+	}
+
+	// a reservation of several tokens gives back the ones that later
+	// reservations don't wait for
+	l = New(1, 3, c)
+	l.ReserveN(3)
+	r1 = l.ReserveN(2)
+	r2 = l.Reserve()
+	if r1.Delay() != 2*time.Second || r2.Delay() != 3*time.Second {
+		t.Fatalf("reservations wait %v and %v, want 2s and 3s", r1.Delay(), r2.Delay())
+	}
+	r1.Cancel()
+	if got := l.Tokens(); got != -2 {
+		t.Errorf("Tokens = %v after the cancel, want -2", got)
+	}
+}
+
+func TestWait(t *testing.T) {
+	leakcheck.Check(t)
+	c := clock.NewFake(time.Time{})
+	l := New(2, 1, c)
+	ctx := context.Background()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- l.Wait(ctx) }()
+	c.BlockUntil(1)
+	c.Advance(499 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Wait returned before a token was added")
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// a cancelled Wait gives its token back
+	cctx, cancel := context.WithCancel(ctx)
+	go func() { done <- l.Wait(cctx) }()
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("cancelled Wait = %v", err)
+	}
+	if d := l.Reserve().Delay(); d != 500*time.Millisecond {
+		t.Errorf("next token in %v, want 500ms", d)
+	}
+
+	// the token comes after the deadline
+	tctx, cancel := clock.WithTimeout(ctx, c, 100*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(tctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait past the deadline = %v", err)
+	}
+	if err := l.WaitN(ctx, 2); err != ErrExceedsBurst {
+		t.Errorf("WaitN(2) with a burst of 1 = %v", err)
+	}
+}
+
+func TestInf(t *testing.T) {
+	l := New(Inf, 0, clock.NewFake(time.Time{}))
+	for i := 0; i < 100; i++ {
+		if !l.Allow() || l.Reserve().Delay() != 0 || l.Wait(context.Background()) != nil {
+			t.Fatal("an infinite rate limited an event")
+		}
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	k := NewKeyed[string](1, 1, time.Minute, c)
+	if !k.Allow("a") || !k.Allow("b") {
+		t.Fatal("first event of a key was denied")
+	}
+	if k.Allow("a") {
+		t.Error("second event of a was allowed")
+	}
+	if k.Len() != 2 {
+		t.Errorf("Len = %d", k.Len())
+	}
+
+	c.Advance(30 * time.Second)
+	k.Allow("a")
+	c.Advance(30 * time.Second)
+	// b was idle for a minute, a for half of it
+	if n := k.Evict(); n != 1 || k.Len() != 1 {
+		t.Errorf("Evict removed %d, %d left", n, k.Len())
+	}
+
+	// the sweep in Get evicts a once it has been idle long enough
+	c.Advance(2 * time.Minute)
+	k.Get("c")
+	if k.Len() != 1 {
+		t.Errorf("Len = %d after a sweep, want 1", k.Len())
+	}
+	// a limiter still owing tokens stays
+	slow := NewKeyed[string](Every(time.Hour), 1, time.Minute, c)
+	l := slow.Get("a")
+	l.Reserve()
+	l.Reserve()
+	c.Advance(time.Minute)
+	if slow.Evict(); slow.Len() != 1 {
+		t.Error("evicted a limiter with pending reservations")
+	}
+}
+
+func TestKeyedKeepsDrainedLimiters(t *testing.T) {
+	c := clock.NewFake(time.Time{})
+	k := NewKeyed[string](1.0/60, 5, time.Second, c)
+	for i := 0; i < 5; i++ {
+		if !k.Allow("a") {
+			t.Fatalf("event %d of the burst was denied", i)
+		}
+	}
+	// idle long enough, but the bucket has only refilled 2s/60s of a token
+	c.Advance(2 * time.Second)
+	if n := k.Evict(); n != 0 {
+		t.Errorf("Evict removed %d drained limiters", n)
+	}
+	if k.Allow("a") {
+		t.Error("a drained key got a fresh burst after being idle")
+	}
+	// once full again the limiter goes
+	c.Advance(5 * time.Minute)
+	if n := k.Evict(); n != 1 || k.Len() != 0 {
+		t.Errorf("Evict removed %d, %d left, want the refilled limiter gone", n, k.Len())
+	}
+}
@@ -0,0 +1,97 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions on goroutines and waits for all of them. The first
+// function to return an error cancels the context of the group, which tells
+// the others to stop, and is the error Wait returns.
+//
+// The zero value is a group without a context that doesn't limit the
+// number of goroutines.
+type Group struct {
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	sem    chan struct{} // one slot per goroutine if there's a limit
+
+	once sync.Once
+	err  error
+}
+
+// WithContext returns a new group and the context its functions should
+// use, which is cancelled on the first error or once Wait returns.
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit makes Go block while n functions of the group are running. A
+// negative n removes the limit. The limit must not be changed while
+// functions are running.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic("syncx: SetLimit while functions of the group are running")
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs f on a new goroutine, waiting for a free slot first if the group
+// has a limit.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(f)
+}
+
+// TryGo runs f on a new goroutine if the limit allows it without waiting,
+// and reports whether it did.
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+func (g *Group) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		if err := f(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// Wait blocks until all functions have returned and returns the first
+// error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
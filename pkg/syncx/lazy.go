@@ -0,0 +1,35 @@
+package syncx
+
+import "sync"
+
+// Lazy is a value computed on first use. Unlike sync.OnceValues, which
+// keeps the first result forever, an error isn't kept: the next Get tries
+// again. Concurrent calls of Get wait for the call in progress instead of
+// calling the function again.
+type Lazy[T any] struct {
+	f    func() (T, error)
+	mu   sync.Mutex
+	done bool
+	v    T
+}
+
+// NewLazy returns a value computed by f on first use.
+func NewLazy[T any](f func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{f: f}
+}
+
+// Get returns the value, computing it if it hasn't been yet or if computing
+// it failed the last time.
+func (l *Lazy[T]) Get() (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return l.v, nil
+	}
+	v, err := l.f()
+	if err != nil {
+		return v, err
+	}
+	l.v, l.done = v, true
+	return v, nil
+}
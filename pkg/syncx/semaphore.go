@@ -0,0 +1,111 @@
+package syncx
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Semaphore limits the total weight of the work running at the same time.
+// Waiters are served in order, so a heavy Acquire isn't starved by a stream
+// of light ones.
+type Semaphore struct {
+	size    int64
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // of *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // closed when the weight is acquired
+}
+
+// NewSemaphore returns a semaphore allowing a total weight of n.
+func NewSemaphore(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire blocks until a weight of n is available and takes it, or returns
+// ctx.Err() without taking anything if ctx is done first. Asking for more
+// than the size of the semaphore blocks until ctx is done.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// acquired just as ctx was done, give it back
+			s.cur -= n
+			s.notify()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// waiters behind a removed front may fit now
+			if front && s.size > s.cur {
+				s.notify()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a weight of n if it's available without waiting and
+// reports whether it did.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release gives back a weight of n. It panics if more is released than was
+// acquired.
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("syncx: semaphore released more than held")
+	}
+	s.notify()
+}
+
+// notify wakes the waiters at the front that fit. s.mu must be held.
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			// keep the order, the next waiters wait behind this one
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,4 @@
+// Package syncx adds the synchronization primitives the sync package lacks:
+// a weighted Semaphore, a Group of goroutines that fails as a whole, and
+// Lazy values that are computed once but retried after an error.
+package syncx
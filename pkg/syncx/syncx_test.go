@@ -0,0 +1,215 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestSemaphore(t *testing.T) {
+	leakcheck.Check(t)
+	ctx := context.Background()
+	s := NewSemaphore(10)
+	var cur, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		n := int64(i%4 + 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Acquire(ctx, n); err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Release(n)
+			c := cur.Add(n)
+			for p := peak.Load(); c > p && !peak.CompareAndSwap(p, c); p = peak.Load() {
+			}
+			time.Sleep(100 * time.Microsecond)
+			cur.Add(-n)
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 10 {
+		t.Errorf("weight %d was in use with a semaphore of 10", p)
+	}
+	if !s.TryAcquire(10) {
+		t.Error("TryAcquire of the whole semaphore failed after everything was released")
+	}
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire succeeded on a full semaphore")
+	}
+	s.Release(10)
+}
+
+func TestSemaphoreOrder(t *testing.T) {
+	leakcheck.Check(t)
+	ctx := context.Background()
+	s := NewSemaphore(3)
+	s.Acquire(ctx, 2)
+	// the heavy waiter comes first, the light one must not overtake it
+	heavy, light := make(chan struct{}), make(chan struct{})
+	go func() { s.Acquire(ctx, 3); close(heavy) }()
+	waitWaiters(s, 1)
+	go func() { s.Acquire(ctx, 1); close(light) }()
+	waitWaiters(s, 2)
+	if s.TryAcquire(1) {
+		t.Error("TryAcquire jumped the queue")
+	}
+	s.Release(2)
+	<-heavy
+	select {
+	case <-light:
+		t.Fatal("light waiter acquired while the heavy one holds everything")
+	default:
+	}
+	s.Release(3)
+	<-light
+	s.Release(1)
+}
+
+func TestSemaphoreCancel(t *testing.T) {
+	leakcheck.Check(t)
+	s := NewSemaphore(2)
+	s.Acquire(context.Background(), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- s.Acquire(ctx, 2) }()
+	waitWaiters(s, 1)
+	// a light waiter blocked behind the cancelled one gets through
+	light := make(chan struct{})
+	go func() { s.Acquire(context.Background(), 1); close(light) }()
+	waitWaiters(s, 2)
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Acquire = %v, want Canceled", err)
+	}
+	<-light
+	s.Release(2)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx, 3); err != context.DeadlineExceeded {
+		t.Errorf("Acquire beyond the size = %v", err)
+	}
+}
+
+func waitWaiters(s *Semaphore, n int) {
+	for {
+		s.mu.Lock()
+		l := s.waiters.Len()
+		s.mu.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	leakcheck.Check(t)
+	errFirst := errors.New("first")
+	g, ctx := WithContext(context.Background())
+	var cancelled atomic.Int32
+	for i := 0; i < 5; i++ {
+		g.Go(func() error {
+			if i == 2 {
+				return errFirst
+			}
+			<-ctx.Done()
+			cancelled.Add(1)
+			return ctx.Err()
+		})
+	}
+	if err := g.Wait(); err != errFirst {
+		t.Errorf("Wait = %v, want %v", err, errFirst)
+	}
+	if cancelled.Load() != 4 {
+		t.Errorf("%d siblings were cancelled, want 4", cancelled.Load())
+	}
+	if context.Cause(ctx) != errFirst {
+		t.Errorf("Cause = %v", context.Cause(ctx))
+	}
+
+	g, ctx = WithContext(context.Background())
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != nil || ctx.Err() == nil {
+		t.Errorf("Wait = %v, context %v; want nil and a cancelled context", err, ctx.Err())
+	}
+
+	var zero Group
+	zero.Go(func() error { return errFirst })
+	if err := zero.Wait(); err != errFirst {
+		t.Errorf("zero Group Wait = %v", err)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	leakcheck.Check(t)
+	var g Group
+	g.SetLimit(2)
+	var cur, peak atomic.Int32
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		g.Go(func() error {
+			if c := cur.Add(1); c > peak.Load() {
+				peak.Store(c)
+			}
+			<-release
+			cur.Add(-1)
+			return nil
+		})
+	}
+	if g.TryGo(func() error { return nil }) {
+		t.Error("TryGo ran a function beyond the limit")
+	}
+	close(release)
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			if c := cur.Add(1); c > 2 {
+				t.Errorf("%d functions running with a limit of 2", c)
+			}
+			time.Sleep(100 * time.Microsecond)
+			cur.Add(-1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLazy(t *testing.T) {
+	leakcheck.Check(t)
+	var calls atomic.Int32
+	fail := true
+	l := NewLazy(func() (int, error) {
+		calls.Add(1)
+		if fail {
+			return 0, errors.New("not yet")
+		}
+		return 42, nil
+	})
+	if _, err := l.Get(); err == nil {
+		t.Fatal("Get didn't return the error")
+	}
+	fail = false
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.Get(); v != 42 || err != nil {
+				t.Errorf("Get = %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 2 {
+		t.Errorf("the function was called %d times, want 2", calls.Load())
+	}
+}
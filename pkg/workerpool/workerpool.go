@@ -0,0 +1,212 @@
+// Package workerpool runs jobs on a fixed number of goroutines.
+//
+// The concurrency lesson starts one goroutine per task, which is fine for
+// three tasks and not for hundreds of thousands. A Pool starts its workers
+// once and hands them jobs through a bounded queue, so Submit blocks when the
+// workers fall behind instead of piling up goroutines.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrClosed is returned when submitting to a pool that is shutting down.
+	ErrClosed = errors.New("workerpool: pool is closed")
+	// ErrQueueFull is returned by TrySubmit when the queue has no room.
+	ErrQueueFull = errors.New("workerpool: queue is full")
+)
+
+// Job is a unit of work. Its context is cancelled when the context given to
+// Submit is, or when Shutdown gives up waiting.
+type Job[T any] func(ctx context.Context) (T, error)
+
+// Stats is a snapshot of what a pool is doing.
+type Stats struct {
+	Queued    int64 // jobs waiting for a worker
+	Running   int64 // jobs being run
+	Completed int64 // jobs that returned, including Failed ones
+	Failed    int64 // jobs that returned an error or panicked
+}
+
+// Task is the handle to a submitted job and its result.
+type Task[T any] struct {
+	ctx   context.Context
+	job   Job[T]
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done is closed once the job has returned.
+func (t *Task[T]) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait blocks until the job has returned and returns its result, or until ctx
+// is done.
+func (t *Task[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-t.done:
+		return t.value, t.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Pool runs submitted jobs on a fixed number of workers.
+type Pool[T any] struct {
+	queue  chan *Task[T]
+	wg     sync.WaitGroup
+	ctx    context.Context // cancelled when Shutdown gives up
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex  // held for reading while submitting
+	closed   bool          // set with mu held for writing
+	closing  chan struct{} // closed first, to wake up blocked submitters
+	shutdown sync.Once
+
+	queued, running, completed, failed atomic.Int64
+}
+
+// New starts a pool with the given number of workers and room for queue jobs
+// waiting for them. It panics if workers is less than 1.
+func New[T any](workers, queue int) *Pool[T] {
+	if workers < 1 {
+		panic("workerpool: workers must be at least 1")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		queue:   make(chan *Task[T], max(queue, 0)),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues job, blocking while the queue is full. It returns ErrClosed
+// after Shutdown has been called and ctx.Err() if ctx is done before the job
+// was queued.
+func (p *Pool[T]) Submit(ctx context.Context, job Job[T]) (*Task[T], error) {
+	return p.submit(ctx, job, true)
+}
+
+// TrySubmit is like Submit but returns ErrQueueFull instead of blocking.
+func (p *Pool[T]) TrySubmit(ctx context.Context, job Job[T]) (*Task[T], error) {
+	return p.submit(ctx, job, false)
+}
+
+func (p *Pool[T]) submit(ctx context.Context, job Job[T], block bool) (*Task[T], error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t := &Task[T]{ctx: ctx, job: job, done: make(chan struct{})}
+	// count the job before a worker can pick it up
+	p.queued.Add(1)
+	select {
+	case p.queue <- t:
+		return t, nil
+	default:
+	}
+	if !block {
+		p.queued.Add(-1)
+		return nil, ErrQueueFull
+	}
+	select {
+	case p.queue <- t:
+		return t, nil
+	case <-ctx.Done():
+		p.queued.Add(-1)
+		return nil, ctx.Err()
+	case <-p.closing:
+		p.queued.Add(-1)
+		return nil, ErrClosed
+	}
+}
+
+func (p *Pool[T]) work() {
+	defer p.wg.Done()
+	for t := range p.queue {
+		p.queued.Add(-1)
+		p.running.Add(1)
+		t.value, t.err = p.run(t)
+		p.running.Add(-1)
+		if t.err != nil {
+			p.failed.Add(1)
+		}
+		p.completed.Add(1)
+		close(t.done)
+	}
+}
+
+// run calls the job with a context that is cancelled with either the
+// submitter's or the pool's context.
+func (p *Pool[T]) run(t *Task[T]) (value T, err error) {
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("workerpool: job panicked: %v", r)
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return value, err
+	}
+	return t.job(ctx)
+}
+
+// Stats returns the current counters of the pool.
+func (p *Pool[T]) Stats() Stats {
+	return Stats{
+		Queued:    p.queued.Load(),
+		Running:   p.running.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+	}
+}
+
+// Shutdown stops accepting jobs and waits for the queued and running ones to
+// finish. If ctx is done first, the context of the remaining jobs is
+// cancelled and Shutdown returns ctx.Err() without waiting for them.
+// Shutdown may be called more than once.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.shutdown.Do(func() {
+		close(p.closing)
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+	})
+
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,237 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ssukruth/little-engine-go/pkg/leakcheck"
+)
+
+func TestResults(t *testing.T) {
+	leakcheck.Check(t)
+	p := New[int](4, 8)
+	ctx := context.Background()
+	errOdd := errors.New("odd")
+	var tasks []*Task[int]
+	for i := 0; i < 20; i++ {
+		task, err := p.Submit(ctx, func(context.Context) (int, error) {
+			if i%2 == 1 {
+				return 0, errOdd
+			}
+			return i * i, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+	for i, task := range tasks {
+		v, err := task.Wait(ctx)
+		switch {
+		case i%2 == 1 && err != errOdd:
+			t.Errorf("job %d: err = %v, want %v", i, err, errOdd)
+		case i%2 == 0 && (err != nil || v != i*i):
+			t.Errorf("job %d = %d, %v, want %d", i, v, err, i*i)
+		}
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s != (Stats{Completed: 20, Failed: 10}) {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	p := New[int](1, 0)
+	defer p.Shutdown(context.Background())
+	task, err := p.Submit(context.Background(), func(context.Context) (int, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := task.Wait(context.Background()); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Wait = %v, want the panic", err)
+	}
+}
+
+// block returns a job that blocks until release is closed, and a channel
+// receiving a value whenever such a job starts.
+func block() (Job[int], chan struct{}, chan struct{}) {
+	started, release := make(chan struct{}, 100), make(chan struct{})
+	return func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return 0, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}, started, release
+}
+
+func TestBoundedQueue(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](2, 1)
+	job, started, release := block()
+	for i := 0; i < 3; i++ {
+		if _, err := p.Submit(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+	if _, err := p.TrySubmit(ctx, job); err != ErrQueueFull {
+		t.Errorf("TrySubmit on a full queue = %v, want ErrQueueFull", err)
+	}
+	if s := p.Stats(); s != (Stats{Queued: 1, Running: 2}) {
+		t.Errorf("Stats = %+v", s)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(timeout, job); err != context.DeadlineExceeded {
+		t.Errorf("Submit on a full queue = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s != (Stats{Completed: 3}) {
+		t.Errorf("Stats after Shutdown = %+v", s)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	leakcheck.Check(t)
+	ctx := context.Background()
+	p := New[int](3, 10)
+	var ran atomic.Int64
+	var tasks []*Task[int]
+	for i := 0; i < 50; i++ {
+		task, err := p.Submit(ctx, func(context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			ran.Add(1)
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ran.Load() != 50 {
+		t.Errorf("%d jobs ran before Shutdown returned, want 50", ran.Load())
+	}
+	for _, task := range tasks {
+		select {
+		case <-task.Done():
+		default:
+			t.Fatal("Shutdown returned before a task was done")
+		}
+	}
+	if _, err := p.Submit(ctx, nil); err != ErrClosed {
+		t.Errorf("Submit after Shutdown = %v, want ErrClosed", err)
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown = %v", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := New[int](1, 1)
+	job, started, _ := block()
+	running, err := p.Submit(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, err := p.Submit(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// blocks on the full queue until Shutdown
+	blocked := make(chan error)
+	go func() {
+		_, err := p.Submit(context.Background(), job)
+		blocked <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if err := <-blocked; err != ErrClosed {
+		t.Errorf("blocked Submit = %v, want ErrClosed", err)
+	}
+	for _, task := range []*Task[int]{running, queued} {
+		if _, err := task.Wait(context.Background()); err != context.Canceled {
+			t.Errorf("job after Shutdown gave up = %v, want Canceled", err)
+		}
+	}
+}
+
+func TestManyJobs(t *testing.T) {
+	const jobs, workers = 100000, 8
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+	p := New[int](workers, 64)
+	var sum, peak atomic.Int64
+	go func() {
+		for i := 0; i < jobs; i++ {
+			if _, err := p.Submit(ctx, func(context.Context) (int, error) {
+				sum.Add(1)
+				if n := int64(runtime.NumGoroutine()); n > peak.Load() {
+					peak.Store(n)
+				}
+				return 0, nil
+			}); err != nil {
+				panic(err)
+			}
+		}
+		p.Shutdown(ctx)
+	}()
+	for p.Stats().Completed < jobs {
+		time.Sleep(time.Millisecond)
+	}
+	if sum.Load() != jobs {
+		t.Errorf("ran %d jobs, want %d", sum.Load(), jobs)
+	}
+	// the workers, the submitter and the test's own goroutines
+	if limit := int64(before + workers + 4); peak.Load() > limit {
+		t.Errorf("%d goroutines were running, want at most %d", peak.Load(), limit)
+	}
+}
+
+func ExamplePool() {
+	ctx := context.Background()
+	p := New[string](2, 10)
+	var tasks []*Task[string]
+	for _, name := range []string{"task1", "task2", "task3"} {
+		task, _ := p.Submit(ctx, func(context.Context) (string, error) {
+			return "done with " + name, nil
+		})
+		tasks = append(tasks, task)
+	}
+	p.Shutdown(ctx)
+	for _, task := range tasks {
+		fmt.Println(task.Wait(ctx))
+	}
+	fmt.Printf("%+v\n", p.Stats())
+	// Output:
+	// done with task1 <nil>
+	// done with task2 <nil>
+	// done with task3 <nil>
+	// {Queued:0 Running:0 Completed:3 Failed:0}
+}